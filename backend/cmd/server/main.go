@@ -2,58 +2,60 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/enkinvsh/focus-backend/internal/api"
+	"github.com/enkinvsh/focus-backend/internal/bot"
 	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/scheduler"
+	"github.com/enkinvsh/focus-backend/internal/store"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
-type rateLimiter struct {
-	mu       sync.Mutex
-	requests map[string][]time.Time
-}
-
-var limiter = &rateLimiter{requests: make(map[string][]time.Time)}
-
-func (rl *rateLimiter) allow(ip string, limit int, window time.Duration) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+var limiter = api.NewRateLimiter()
 
-	now := time.Now()
-	cutoff := now.Add(-window)
+func main() {
+	godotenv.Load()
 
-	var valid []time.Time
-	for _, t := range rl.requests[ip] {
-		if t.After(cutoff) {
-			valid = append(valid, t)
+	if len(os.Args) > 1 && os.Args[1] == "setup-bot" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := bot.Setup(ctx, os.Getenv("WEBHOOK_URL"), os.Getenv("WEBHOOK_SECRET")); err != nil {
+			log.Fatal("Bot setup failed:", err)
 		}
+		log.Println("Bot setup complete")
+		return
 	}
 
-	if len(valid) >= limit {
-		rl.requests[ip] = valid
-		return false
-	}
-
-	rl.requests[ip] = append(valid, now)
-	return true
-}
-
-func main() {
-	godotenv.Load()
-
 	if err := db.Connect(); err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	defer db.Close()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal("Migrate failed: ", err)
+		}
+		return
+	}
+
+	// Replicas starting together serialize on the migration advisory lock.
+	applied, err := db.MigrateUp(context.Background())
+	if err != nil {
+		log.Fatal("Failed to apply migrations:", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %03d_%s", m.Version, m.Name)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 	r.SetTrustedProxies([]string{
@@ -68,7 +70,7 @@ func main() {
 
 	r.Use(func(c *gin.Context) {
 		ip := c.ClientIP()
-		if !limiter.allow(ip, 100, time.Minute) {
+		if !limiter.Allow(ip, 100, time.Minute) {
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
 			return
 		}
@@ -97,7 +99,21 @@ func main() {
 		c.Next()
 	})
 
-	api.SetupRoutes(r)
+	updates := bot.NewDispatcher(8, 256)
+	updates.Start()
+
+	maxAge := api.DefaultInitDataMaxAge
+	if v := os.Getenv("INIT_DATA_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid INIT_DATA_MAX_AGE: %v", err)
+		}
+		maxAge = d
+	}
+	auth := api.NewInitDataValidator(os.Getenv("BOT_TOKEN"), os.Getenv("BOT_ID"), maxAge)
+
+	pg := store.NewPostgres(db.Pool)
+	api.SetupRoutes(r, api.NewServer(pg, pg, pg), updates, auth)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -109,6 +125,31 @@ func main() {
 		Handler: r,
 	}
 
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+
+	var poller *bot.Poller
+	if os.Getenv("BOT_MODE") == "polling" {
+		// Commands only; the poller removes any registered webhook.
+		go func() {
+			if err := bot.Setup(bgCtx, "", ""); err != nil {
+				log.Printf("Bot setup failed: %v", err)
+			}
+		}()
+		poller = bot.NewPoller()
+		poller.Start(bgCtx)
+	} else if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		go func() {
+			if err := bot.Setup(bgCtx, webhookURL, os.Getenv("WEBHOOK_SECRET")); err != nil {
+				log.Printf("Bot setup failed: %v", err)
+				return
+			}
+			log.Println("Bot webhook and commands registered")
+		}()
+	}
+
+	sched := scheduler.New(scheduler.ReminderJob(), scheduler.DigestJob(), scheduler.ReviewJob(), scheduler.CleanupJob())
+	sched.Start(bgCtx)
+
 	go func() {
 		log.Printf("Server starting on :%s", port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -121,12 +162,70 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	stopBackground()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	updates.Stop()
+	sched.Wait()
+	if poller != nil {
+		poller.Wait()
+	}
 
 	log.Println("Server exited gracefully")
 }
+
+// runMigrate implements "migrate up", "migrate down [steps]" and
+// "migrate status".
+func runMigrate(args []string) error {
+	ctx := context.Background()
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		for _, m := range applied {
+			log.Printf("Applied %03d_%s", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := db.MigrateDown(ctx, steps)
+		for _, m := range reverted {
+			log.Printf("Reverted %03d_%s", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		status, err := db.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%03d_%-24s %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down [steps] or status)", cmd)
+	}
+}
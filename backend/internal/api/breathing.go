@@ -0,0 +1,80 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxBreathingDuration = time.Hour
+
+func (s *Server) CreateBreathingSession(c *gin.Context) {
+	user := GetUser(c)
+
+	var req models.CreateBreathingSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if req.EndedAt.Before(req.StartedAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ended_at must not be before started_at"})
+		return
+	}
+	if req.EndedAt.Sub(req.StartedAt) > maxBreathingDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session too long (max 1 hour)"})
+		return
+	}
+	if req.EndedAt.After(time.Now().Add(5 * time.Minute)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ended_at is in the future"})
+		return
+	}
+
+	session := models.BreathingSession{
+		StartedAt: req.StartedAt,
+		EndedAt:   req.EndedAt,
+		Cycles:    req.Cycles,
+		Aborted:   req.Aborted,
+	}
+	if err := s.Activity.CreateBreathingSession(c.Request.Context(), user.ID, &session); err != nil {
+		log.Printf("CreateBreathingSession error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save session"})
+		return
+	}
+
+	s.Record(c.Request.Context(), user.ID, events.BreathingSession, gin.H{
+		"session_id":   session.ID,
+		"cycles":       session.Cycles,
+		"aborted":      session.Aborted,
+		"duration_sec": int(session.EndedAt.Sub(session.StartedAt).Seconds()),
+	})
+
+	c.JSON(http.StatusCreated, session)
+}
+
+func (s *Server) GetBreathingSessions(c *gin.Context) {
+	user := GetUser(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	sessions, err := s.Activity.ListBreathingSessions(c.Request.Context(), user.ID, limit, offset)
+	if err != nil {
+		log.Printf("GetBreathingSessions error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "limit": limit, "offset": offset})
+}
@@ -1,15 +1,23 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
 	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/enkinvsh/focus-backend/internal/recurrence"
 	"github.com/enkinvsh/focus-backend/internal/services"
+	"github.com/enkinvsh/focus-backend/internal/store"
+	"github.com/enkinvsh/focus-backend/internal/timezone"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,12 +25,14 @@ const (
 	defaultLimit = 50
 	maxLimit     = 200
 	maxAudioSize = 5 * 1024 * 1024 // 5MB
+	maxTextSize  = 2000
 )
 
-func GetTasks(c *gin.Context) {
+func (s *Server) GetTasks(c *gin.Context) {
 	user := GetUser(c)
 	taskType := c.DefaultQuery("type", "Task")
 	completed := c.DefaultQuery("completed", "false") == "true"
+	archived := c.DefaultQuery("archived", "false") == "true"
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
@@ -33,38 +43,31 @@ func GetTasks(c *gin.Context) {
 		offset = 0
 	}
 
-	rows, err := db.Pool.Query(c.Request.Context(), `
-		SELECT id, title, original_input, task_type, priority, completed, created_at
-		FROM tasks 
-		WHERE user_id = $1 AND task_type = $2 AND completed = $3
-		ORDER BY priority ASC, created_at DESC
-		LIMIT $4 OFFSET $5
-	`, user.ID, taskType, completed, limit, offset)
+	manual := false
+	if prefs, err := s.Users.Preferences(c.Request.Context(), user.ID); err != nil {
+		log.Printf("GetTasks preferences error: %v", err)
+	} else {
+		manual = prefs.TaskOrder == models.TaskOrderManual
+	}
+
+	tasks, err := s.Tasks.ListTasks(c.Request.Context(), user.ID, store.TaskFilter{
+		Type:      taskType,
+		Completed: completed,
+		Archived:  archived,
+		Manual:    manual,
+		Limit:     limit,
+		Offset:    offset,
+	})
 	if err != nil {
 		log.Printf("GetTasks error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tasks"})
 		return
 	}
-	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var t models.Task
-		if err := rows.Scan(&t.ID, &t.Title, &t.OriginalInput, &t.TaskType, &t.Priority, &t.Completed, &t.CreatedAt); err != nil {
-			log.Printf("GetTasks scan error: %v", err)
-			continue
-		}
-		t.UserID = user.ID
-		tasks = append(tasks, t)
-	}
-
-	if tasks == nil {
-		tasks = []models.Task{}
-	}
 	c.JSON(http.StatusOK, gin.H{"tasks": tasks, "limit": limit, "offset": offset})
 }
 
-func CreateTask(c *gin.Context) {
+func (s *Server) CreateTask(c *gin.Context) {
 	user := GetUser(c)
 
 	var req models.CreateTaskRequest
@@ -77,30 +80,56 @@ func CreateTask(c *gin.Context) {
 		req.Priority = 2
 	}
 
-	var task models.Task
-	err := db.Pool.QueryRow(c.Request.Context(), `
-		INSERT INTO tasks (user_id, title, original_input, task_type, priority)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at
-	`, user.ID, req.Title, req.Original, req.Type, req.Priority).Scan(&task.ID, &task.CreatedAt)
+	loc := s.Users.Location(c.Request.Context(), user.ID)
 
-	if err != nil {
+	var dueAt *time.Time
+	if req.DueAt != nil {
+		t, err := services.ParseDueAt(*req.DueAt, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		dueAt = &t
+	}
+
+	var nextOccurrence *string
+	if req.Recurrence != nil {
+		if req.Type != "Routine" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "recurrence is only supported for Routine tasks"})
+			return
+		}
+		if err := req.Recurrence.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		first := req.Recurrence.First(timezone.Today(loc)).Format(time.DateOnly)
+		nextOccurrence = &first
+	}
+
+	task := models.Task{
+		UserID:         user.ID,
+		Title:          req.Title,
+		OriginalInput:  req.Original,
+		TaskType:       req.Type,
+		Priority:       req.Priority,
+		DueAt:          dueAt,
+		Recurrence:     req.Recurrence,
+		NextOccurrence: nextOccurrence,
+	}
+	if err := s.Tasks.CreateTask(c.Request.Context(), &task); err != nil {
 		log.Printf("CreateTask error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
 		return
 	}
 
-	task.UserID = user.ID
-	task.Title = req.Title
-	task.OriginalInput = req.Original
-	task.TaskType = req.Type
-	task.Priority = req.Priority
-	task.Completed = false
+	s.Record(c.Request.Context(), user.ID, events.TaskCreated, gin.H{
+		"task_id": task.ID, "title": task.Title, "type": task.TaskType, "source": events.SourceText,
+	})
 
 	c.JSON(http.StatusCreated, task)
 }
 
-func UpdateTask(c *gin.Context) {
+func (s *Server) UpdateTask(c *gin.Context) {
 	user := GetUser(c)
 	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -114,38 +143,113 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
-	var completedAt *time.Time
-	if req.Completed != nil && *req.Completed {
-		now := time.Now()
-		completedAt = &now
+	ctx := c.Request.Context()
+	loc := s.Users.Location(ctx, user.ID)
+
+	// Every field is validated before the first write below.
+	var dueAt *time.Time
+	if req.DueAt.Set && req.DueAt.Value != nil {
+		t, err := services.ParseDueAt(*req.DueAt.Value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		dueAt = &t
 	}
 
-	result, err := db.Pool.Exec(c.Request.Context(), `
-		UPDATE tasks 
-		SET 
-			title = COALESCE($1, title),
-			priority = COALESCE($2, priority),
-			completed = COALESCE($3, completed),
-			completed_at = $4,
-			updated_at = NOW()
-		WHERE id = $5 AND user_id = $6
-	`, req.Title, req.Priority, req.Completed, completedAt, taskID, user.ID)
+	var nextOccurrence *string
+	if req.Recurrence != nil {
+		if err := req.Recurrence.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task, err := s.Tasks.GetTask(ctx, user.ID, taskID)
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("UpdateTask error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
+			return
+		}
+		if task.TaskType != "Routine" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "recurrence is only supported for Routine tasks"})
+			return
+		}
+		first := req.Recurrence.First(timezone.Today(loc)).Format(time.DateOnly)
+		nextOccurrence = &first
+	}
+
+	// Completing a routine logs the occurrence and rolls it forward instead
+	// of closing the task; the remaining fields are applied below as usual.
+	var routine *recurrence.Completion
+	if req.Completed != nil && *req.Completed {
+		done, err := s.Tasks.CompleteRoutine(ctx, user.ID, taskID, loc)
+		switch {
+		case err == nil:
+			// The completion already moved next_occurrence on; a new rule
+			// takes effect from there.
+			routine = done
+			req.Completed = nil
+			nextOccurrence = nil
+		case errors.Is(err, store.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		case !errors.Is(err, recurrence.ErrNotRecurring):
+			log.Printf("UpdateTask routine error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
+			return
+		}
+	}
 
+	change, err := s.Tasks.UpdateTask(ctx, user.ID, taskID, store.TaskUpdate{
+		Title:          req.Title,
+		Priority:       req.Priority,
+		Completed:      req.Completed,
+		DueSet:         req.DueAt.Set,
+		DueAt:          dueAt,
+		Recurrence:     req.Recurrence,
+		NextOccurrence: nextOccurrence,
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
 	if err != nil {
 		log.Printf("UpdateTask error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
 		return
 	}
 
-	if result.RowsAffected() == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+	title := change.Title
+	if req.Completed != nil && *req.Completed && !change.WasCompleted {
+		s.Record(ctx, user.ID, events.TaskCompleted, gin.H{"task_id": taskID, "title": title})
+	}
+	s.recordParentChange(ctx, user.ID, change.Parent)
+
+	if routine != nil {
+		if !routine.AlreadyDone {
+			s.Record(ctx, user.ID, events.TaskCompleted, gin.H{
+				"task_id": taskID, "title": title, "occurrence": routine.Occurrence.Format(time.DateOnly),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "next_occurrence": routine.Next.Format(time.DateOnly)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "parent_completed": change.Parent != nil && change.Parent.Completed})
 }
 
-func DeleteTask(c *gin.Context) {
+// recordParentChange logs a parent completed by checking off or deleting
+// its last open subtask.
+func (s *Server) recordParentChange(ctx context.Context, userID int64, p *store.ParentChange) {
+	if p != nil && p.Completed {
+		s.Record(ctx, userID, events.TaskCompleted, gin.H{"task_id": p.ID, "title": p.Title, "subtasks": true})
+	}
+}
+
+func (s *Server) DeleteTask(c *gin.Context) {
 	user := GetUser(c)
 	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -153,72 +257,164 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	result, err := db.Pool.Exec(c.Request.Context(), `
-		DELETE FROM tasks WHERE id = $1 AND user_id = $2
-	`, taskID, user.ID)
-
+	change, err := s.Tasks.DeleteTask(c.Request.Context(), user.ID, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
 	if err != nil {
 		log.Printf("DeleteTask error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
 		return
 	}
 
-	if result.RowsAffected() == 0 {
+	s.Record(c.Request.Context(), user.ID, events.TaskDeleted, gin.H{"task_id": taskID, "title": change.Title})
+	s.recordParentChange(c.Request.Context(), user.ID, change.Parent)
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ReorderTasks takes the list as the user arranged it. Only tasks whose
+// relative order changed get a new position.
+func (s *Server) ReorderTasks(c *gin.Context) {
+	user := GetUser(c)
+
+	var req models.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if len(req.IDs) > maxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many ids (max 200)"})
+		return
+	}
+
+	err := s.Tasks.ReorderTasks(c.Request.Context(), user.ID, req.IDs)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 		return
+	case errors.Is(err, store.ErrInvalidOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("ReorderTasks error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reorder tasks"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
-func GetPreferences(c *gin.Context) {
+func (s *Server) GetPreferences(c *gin.Context) {
 	user := GetUser(c)
 
-	var u models.User
-	err := db.Pool.QueryRow(c.Request.Context(), `
-		SELECT language, timezone, theme_index FROM users WHERE id = $1
-	`, user.ID).Scan(&u.Language, &u.Timezone, &u.ThemeIndex)
-
+	u, err := s.Users.Preferences(c.Request.Context(), user.ID)
 	if err != nil {
-		u = models.User{Language: "en", Timezone: "UTC", ThemeIndex: 0}
+		log.Printf("GetPreferences error: %v", err)
+		d := store.DefaultPreferences()
+		u = &d
 	}
 
 	c.JSON(http.StatusOK, u)
 }
 
-func UpdatePreferences(c *gin.Context) {
+func (s *Server) UpdatePreferences(c *gin.Context) {
 	user := GetUser(c)
 
 	var req struct {
-		Language   *string `json:"language"`
-		Timezone   *string `json:"timezone"`
-		ThemeIndex *int    `json:"theme_index"`
+		Language       *string `json:"language"`
+		Timezone       *string `json:"timezone"`
+		TimezoneOffset *int    `json:"timezone_offset"` // minutes east of UTC
+		ThemeIndex     *int    `json:"theme_index"`
+		DigestEnabled  *bool   `json:"digest_enabled"`
+		DigestHour     *int    `json:"digest_hour"`
+		ReviewEnabled  *bool   `json:"review_enabled"`
+		TaskOrder      *string `json:"task_order"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
+	tz, ok := resolveTimezone(req.Timezone, req.TimezoneOffset)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timezone"})
+		return
+	}
+	req.Timezone = tz
+	if req.DigestHour != nil && (*req.DigestHour < 0 || *req.DigestHour > 23) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "digest_hour must be 0-23"})
+		return
+	}
+	if req.TaskOrder != nil && *req.TaskOrder != models.TaskOrderPriority && *req.TaskOrder != models.TaskOrderManual {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_order must be priority or manual"})
+		return
+	}
 
-	_, err := db.Pool.Exec(c.Request.Context(), `
-		INSERT INTO users (id, first_name, username, language, timezone, theme_index)
-		VALUES ($1, $2, $3, COALESCE($4, 'en'), COALESCE($5, 'UTC'), COALESCE($6, 0))
-		ON CONFLICT (id) DO UPDATE SET
-			language = COALESCE($4, users.language),
-			timezone = COALESCE($5, users.timezone),
-			theme_index = COALESCE($6, users.theme_index),
-			updated_at = NOW()
-	`, user.ID, user.FirstName, user.Username, req.Language, req.Timezone, req.ThemeIndex)
-
+	err := s.Users.UpdatePreferences(c.Request.Context(), profileOf(user), store.PreferencesUpdate{
+		Language:      req.Language,
+		Timezone:      req.Timezone,
+		ThemeIndex:    req.ThemeIndex,
+		DigestEnabled: req.DigestEnabled,
+		DigestHour:    req.DigestHour,
+		ReviewEnabled: req.ReviewEnabled,
+		TaskOrder:     req.TaskOrder,
+	})
 	if err != nil {
 		log.Printf("UpdatePreferences error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update preferences"})
 		return
 	}
 
+	changed := gin.H{}
+	if req.Language != nil {
+		changed["language"] = *req.Language
+	}
+	if req.Timezone != nil {
+		changed["timezone"] = *req.Timezone
+	}
+	if req.ThemeIndex != nil {
+		changed["theme_index"] = *req.ThemeIndex
+	}
+	if req.DigestEnabled != nil {
+		changed["digest_enabled"] = *req.DigestEnabled
+	}
+	if req.DigestHour != nil {
+		changed["digest_hour"] = *req.DigestHour
+	}
+	if req.ReviewEnabled != nil {
+		changed["review_enabled"] = *req.ReviewEnabled
+	}
+	if req.TaskOrder != nil {
+		changed["task_order"] = *req.TaskOrder
+	}
+	if len(changed) > 0 {
+		s.Record(c.Request.Context(), user.ID, events.PreferencesChanged, changed)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
-func CreateTaskFromAudio(c *gin.Context) {
+// resolveTimezone validates an IANA name, falling back to the client's
+// reported UTC offset when the name is missing or unknown (some WebViews
+// report zones Go's tzdata lacks). A nil result leaves the timezone as is.
+func resolveTimezone(name *string, offset *int) (*string, bool) {
+	if name != nil {
+		if _, err := timezone.Load(*name); err == nil {
+			return name, true
+		}
+	}
+	if offset != nil {
+		fixed, err := timezone.FromOffset(*offset)
+		if err != nil {
+			return nil, false
+		}
+		return &fixed, true
+	}
+	return nil, name == nil
+}
+
+func (s *Server) CreateTaskFromAudio(c *gin.Context) {
 	user := GetUser(c)
 
 	file, header, err := c.Request.FormFile("audio")
@@ -250,50 +446,157 @@ func CreateTaskFromAudio(c *gin.Context) {
 
 	taskType := c.DefaultPostForm("type", "Task")
 	language := c.DefaultPostForm("language", "en")
+	splitLong := c.DefaultPostForm("subtasks", "true") != "false"
 
-	parsedTasks, err := services.TranscribeAndParseTasks(audioData, mimeType, taskType, language)
+	loc := s.Users.Location(c.Request.Context(), user.ID)
+	parsedTasks, err := services.TranscribeAndParseTasks(audioData, mimeType, taskType, language, loc, splitLong)
 	if err != nil {
 		log.Printf("TranscribeAndParseTasks error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process audio"})
 		return
 	}
 
-	var tasks []models.Task
-	for _, pt := range parsedTasks {
-		title, _ := pt["title"].(string)
-		priority := 2
-		if p, ok := pt["priority"].(float64); ok {
-			priority = int(p)
-		}
-		tType := taskType
-		if t, ok := pt["type"].(string); ok {
-			tType = t
-		}
+	tasks := s.createParsedTasks(c, user.ID, parsedTasks, "[voice]", events.SourceVoice)
+	c.JSON(http.StatusCreated, gin.H{"tasks": tasks})
+}
 
-		var task models.Task
-		err := db.Pool.QueryRow(c.Request.Context(), `
-			INSERT INTO tasks (user_id, title, original_input, task_type, priority)
-			VALUES ($1, $2, $3, $4, $5)
-			RETURNING id, created_at
-		`, user.ID, title, "[voice]", tType, priority).Scan(&task.ID, &task.CreatedAt)
+func (s *Server) CreateTasksFromText(c *gin.Context) {
+	user := GetUser(c)
 
-		if err != nil {
-			log.Printf("CreateTaskFromAudio insert error: %v", err)
+	var req struct {
+		Text     string `json:"text" binding:"required"`
+		Type     string `json:"type"`
+		Language string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text required"})
+		return
+	}
+	if utf8.RuneCountInString(req.Text) > maxTextSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text too long (max 2000 characters)"})
+		return
+	}
+
+	switch req.Type {
+	case "":
+		req.Type = "Task"
+	case "Task", "Long", "Routine":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task type"})
+		return
+	}
+	if req.Language == "" {
+		req.Language = "en"
+	}
+
+	loc := s.Users.Location(c.Request.Context(), user.ID)
+	parsedTasks, err := services.ParseTextTasks(req.Text, req.Type, req.Language, loc)
+	if err != nil {
+		log.Printf("ParseTextTasks error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process text"})
+		return
+	}
+
+	tasks := s.createParsedTasks(c, user.ID, parsedTasks, req.Text, events.SourceText)
+	c.JSON(http.StatusCreated, gin.H{"tasks": tasks})
+}
+
+// createParsedTasks stores Gemini's tasks, skipping any that fail to insert,
+// along with any proposed subtasks.
+func (s *Server) createParsedTasks(c *gin.Context, userID int64, parsed []services.Task, original, source string) []models.Task {
+	tasks := []models.Task{}
+	for _, pt := range parsed {
+		task := models.Task{
+			UserID:        userID,
+			Title:         pt.Title,
+			OriginalInput: original,
+			TaskType:      pt.Type,
+			Priority:      pt.Priority,
+			DueAt:         pt.DueAt,
+		}
+		if err := s.Tasks.CreateTask(c.Request.Context(), &task); err != nil {
+			log.Printf("Create parsed task error (%s): %v", source, err)
 			continue
 		}
 
-		task.UserID = user.ID
-		task.Title = title
-		task.OriginalInput = "[voice]"
-		task.TaskType = tType
-		task.Priority = priority
-		task.Completed = false
+		s.Record(c.Request.Context(), userID, events.TaskCreated, gin.H{
+			"task_id": task.ID, "title": task.Title, "type": task.TaskType, "source": source,
+		})
+
+		created := 0
+		for _, step := range pt.Subtasks {
+			sub := models.Task{
+				UserID:        userID,
+				ParentID:      &task.ID,
+				Title:         step.Title,
+				OriginalInput: original,
+				TaskType:      task.TaskType,
+				Priority:      task.Priority,
+			}
+			if err := s.Tasks.CreateSubtask(c.Request.Context(), &sub); err != nil {
+				log.Printf("Create parsed subtask error (%s): %v", source, err)
+				continue
+			}
+			created++
+
+			s.Record(c.Request.Context(), userID, events.TaskCreated, gin.H{
+				"task_id": sub.ID, "parent_id": task.ID, "title": sub.Title, "type": sub.TaskType, "source": source,
+			})
+		}
+		task.SetProgress(created, 0)
 		tasks = append(tasks, task)
 	}
+	return tasks
+}
+
+func profileOf(u *TelegramUser) db.Profile {
+	return db.Profile{ID: u.ID, FirstName: u.FirstName, Username: u.Username, LanguageCode: u.Language}
+}
+
+func (s *Server) GetEvents(c *gin.Context) {
+	user := GetUser(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if limit <= 0 || limit > maxLimit {
+		limit = defaultLimit
+	}
 
-	if tasks == nil {
-		tasks = []models.Task{}
+	var before int64
+	if cursor := c.Query("cursor"); cursor != "" {
+		var err error
+		before, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || before < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"tasks": tasks})
+	var types []string
+	for _, param := range c.QueryArray("type") {
+		for _, t := range strings.Split(param, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	list, err := s.Activity.ListEvents(c.Request.Context(), user.ID, types, before, limit)
+	if err != nil {
+		log.Printf("GetEvents error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch events"})
+		return
+	}
+
+	// next_cursor is omitted on the last page.
+	resp := gin.H{"events": list, "limit": limit}
+	if len(list) == limit {
+		resp["next_cursor"] = strconv.FormatInt(list[len(list)-1].ID, 10)
+	}
+	c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,422 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/enkinvsh/focus-backend/internal/stats"
+	"github.com/enkinvsh/focus-backend/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	alice int64 = 1
+	bob   int64 = 2
+)
+
+type testServer struct {
+	router *gin.Engine
+}
+
+// newTestServer wires the API handlers to the in-memory store. The stub
+// auth takes the caller's Telegram ID from the X-User-ID header.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mem := store.NewMemory()
+	s := NewServer(mem, mem, mem)
+
+	r := gin.New()
+	api := r.Group("/api/v1")
+	api.Use(func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.GetHeader("X-User-ID"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization"})
+			return
+		}
+		c.Set("user", &TelegramUser{ID: id, FirstName: "Test"})
+		c.Next()
+	}, s.UserMiddleware())
+	api.GET("/tasks", s.GetTasks)
+	api.POST("/tasks", s.CreateTask)
+	api.PATCH("/tasks/:id", s.UpdateTask)
+	api.DELETE("/tasks/:id", s.DeleteTask)
+	api.GET("/events", s.GetEvents)
+	api.GET("/stats", s.GetStats)
+	api.POST("/breathing/sessions", s.CreateBreathingSession)
+	api.GET("/breathing/sessions", s.GetBreathingSessions)
+	api.GET("/user/preferences", s.GetPreferences)
+	api.PATCH("/user/preferences", s.UpdatePreferences)
+
+	return &testServer{router: r}
+}
+
+// do sends a request as userID and decodes the JSON response into out, if
+// given.
+func (ts *testServer) do(t *testing.T, userID int64, method, path, body string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+func (ts *testServer) createTask(t *testing.T, userID int64, body string) models.Task {
+	t.Helper()
+	var task models.Task
+	if code := ts.do(t, userID, http.MethodPost, "/api/v1/tasks", body, &task); code != http.StatusCreated {
+		t.Fatalf("CreateTask = %d, want 201", code)
+	}
+	return task
+}
+
+func taskPath(id int64) string {
+	return "/api/v1/tasks/" + strconv.FormatInt(id, 10)
+}
+
+func TestCreateTask(t *testing.T) {
+	ts := newTestServer(t)
+
+	task := ts.createTask(t, alice, `{"title":"Buy milk","type":"Task","priority":2}`)
+	if task.ID == 0 || task.UserID != alice || task.Title != "Buy milk" {
+		t.Errorf("task = %+v", task)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing title", `{"type":"Task"}`},
+		{"unknown type", `{"title":"x","type":"Project","priority":2}`},
+		{"priority out of range", `{"title":"x","type":"Task","priority":5}`},
+		{"bad due date", `{"title":"x","type":"Task","priority":2,"due_at":"tomorrow"}`},
+		{"recurrence on a one-off task", `{"title":"x","type":"Task","priority":2,"recurrence":{"freq":"daily"}}`},
+		{"malformed JSON", `{"title":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ts.do(t, alice, http.MethodPost, "/api/v1/tasks", tt.body, nil); code != http.StatusBadRequest {
+				t.Errorf("CreateTask = %d, want 400", code)
+			}
+		})
+	}
+}
+
+func TestGetTasks(t *testing.T) {
+	ts := newTestServer(t)
+	low := ts.createTask(t, alice, `{"title":"Low","type":"Task","priority":3}`)
+	high := ts.createTask(t, alice, `{"title":"High","type":"Task","priority":1}`)
+	ts.createTask(t, alice, `{"title":"Project","type":"Long","priority":2}`)
+	ts.createTask(t, bob, `{"title":"Bob's","type":"Task","priority":2}`)
+
+	var resp struct {
+		Tasks  []models.Task `json:"tasks"`
+		Limit  int           `json:"limit"`
+		Offset int           `json:"offset"`
+	}
+	if code := ts.do(t, alice, http.MethodGet, "/api/v1/tasks", "", &resp); code != http.StatusOK {
+		t.Fatalf("GetTasks = %d, want 200", code)
+	}
+	if len(resp.Tasks) != 2 || resp.Tasks[0].ID != high.ID || resp.Tasks[1].ID != low.ID {
+		t.Errorf("tasks = %+v, want High then Low", resp.Tasks)
+	}
+	if resp.Limit != defaultLimit || resp.Offset != 0 {
+		t.Errorf("limit, offset = %d, %d", resp.Limit, resp.Offset)
+	}
+
+	if ts.do(t, alice, http.MethodGet, "/api/v1/tasks?type=Long", "", &resp); len(resp.Tasks) != 1 {
+		t.Errorf("Long tasks = %d, want 1", len(resp.Tasks))
+	}
+	if ts.do(t, alice, http.MethodGet, "/api/v1/tasks?limit=1&offset=1", "", &resp); len(resp.Tasks) != 1 ||
+		resp.Tasks[0].ID != low.ID {
+		t.Errorf("second page = %+v, want Low", resp.Tasks)
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	ts := newTestServer(t)
+	task := ts.createTask(t, alice, `{"title":"Draft","type":"Task","priority":2}`)
+
+	var resp map[string]interface{}
+	code := ts.do(t, alice, http.MethodPatch, taskPath(task.ID), `{"title":"Final","completed":true}`, &resp)
+	if code != http.StatusOK || resp["success"] != true {
+		t.Fatalf("UpdateTask = %d %v", code, resp)
+	}
+
+	var got struct {
+		Tasks []models.Task `json:"tasks"`
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/tasks?completed=true", "", &got)
+	if len(got.Tasks) != 1 || got.Tasks[0].Title != "Final" || !got.Tasks[0].Completed {
+		t.Errorf("completed tasks = %+v", got.Tasks)
+	}
+
+	var events struct {
+		Events []struct {
+			Type string `json:"type"`
+		} `json:"events"`
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/events?type=task_completed", "", &events)
+	if len(events.Events) != 1 {
+		t.Errorf("task_completed events = %d, want 1", len(events.Events))
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"invalid id", "/api/v1/tasks/abc", `{"title":"x"}`, http.StatusBadRequest},
+		{"malformed JSON", taskPath(task.ID), `{"title":`, http.StatusBadRequest},
+		{"bad due date", taskPath(task.ID), `{"due_at":"someday"}`, http.StatusBadRequest},
+		{"recurrence on a one-off task", taskPath(task.ID), `{"recurrence":{"freq":"daily"}}`, http.StatusBadRequest},
+		{"missing task", taskPath(task.ID + 100), `{"title":"x"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ts.do(t, alice, http.MethodPatch, tt.path, tt.body, nil); code != tt.want {
+				t.Errorf("UpdateTask = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteTask(t *testing.T) {
+	ts := newTestServer(t)
+	task := ts.createTask(t, alice, `{"title":"Old","type":"Task","priority":2}`)
+
+	if code := ts.do(t, alice, http.MethodDelete, taskPath(task.ID), "", nil); code != http.StatusOK {
+		t.Fatalf("DeleteTask = %d, want 200", code)
+	}
+	if code := ts.do(t, alice, http.MethodDelete, taskPath(task.ID), "", nil); code != http.StatusNotFound {
+		t.Errorf("second DeleteTask = %d, want 404", code)
+	}
+	if code := ts.do(t, alice, http.MethodDelete, "/api/v1/tasks/abc", "", nil); code != http.StatusBadRequest {
+		t.Errorf("DeleteTask with invalid id = %d, want 400", code)
+	}
+}
+
+// Another user's task must look exactly like a missing one.
+func TestOtherUsersTaskNotFound(t *testing.T) {
+	ts := newTestServer(t)
+	task := ts.createTask(t, alice, `{"title":"Private","type":"Task","priority":2}`)
+
+	if code := ts.do(t, bob, http.MethodPatch, taskPath(task.ID), `{"title":"Mine now"}`, nil); code != http.StatusNotFound {
+		t.Errorf("UpdateTask = %d, want 404", code)
+	}
+	if code := ts.do(t, bob, http.MethodPatch, taskPath(task.ID), `{"completed":true}`, nil); code != http.StatusNotFound {
+		t.Errorf("complete = %d, want 404", code)
+	}
+	if code := ts.do(t, bob, http.MethodDelete, taskPath(task.ID), "", nil); code != http.StatusNotFound {
+		t.Errorf("DeleteTask = %d, want 404", code)
+	}
+
+	var resp struct {
+		Tasks []models.Task `json:"tasks"`
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/tasks", "", &resp)
+	if len(resp.Tasks) != 1 || resp.Tasks[0].Title != "Private" || resp.Tasks[0].Completed {
+		t.Errorf("alice's tasks = %+v, want Private unchanged", resp.Tasks)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	ts := newTestServer(t)
+
+	var prefs models.User
+	if code := ts.do(t, alice, http.MethodGet, "/api/v1/user/preferences", "", &prefs); code != http.StatusOK {
+		t.Fatalf("GetPreferences = %d, want 200", code)
+	}
+	if prefs.Timezone != "UTC" || prefs.DigestEnabled || prefs.TaskOrder != models.TaskOrderPriority {
+		t.Errorf("defaults = %+v", prefs)
+	}
+
+	body := `{"timezone":"Europe/Berlin","digest_enabled":true,"digest_hour":7,"task_order":"manual"}`
+	if code := ts.do(t, alice, http.MethodPatch, "/api/v1/user/preferences", body, nil); code != http.StatusOK {
+		t.Fatalf("UpdatePreferences = %d, want 200", code)
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/user/preferences", "", &prefs)
+	if prefs.Timezone != "Europe/Berlin" || !prefs.DigestEnabled || prefs.DigestHour != 7 ||
+		prefs.TaskOrder != models.TaskOrderManual {
+		t.Errorf("updated = %+v", prefs)
+	}
+
+	var other models.User
+	ts.do(t, bob, http.MethodGet, "/api/v1/user/preferences", "", &other)
+	if other.Timezone != "UTC" {
+		t.Errorf("bob's timezone = %q, want UTC", other.Timezone)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown timezone", `{"timezone":"Mars/Olympus"}`},
+		{"offset out of range", `{"timezone_offset":100000}`},
+		{"digest hour", `{"digest_hour":24}`},
+		{"task order", `{"task_order":"random"}`},
+		{"malformed JSON", `{"timezone":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ts.do(t, alice, http.MethodPatch, "/api/v1/user/preferences", tt.body, nil); code != http.StatusBadRequest {
+				t.Errorf("UpdatePreferences = %d, want 400", code)
+			}
+		})
+	}
+}
+
+func TestCompleteRoutine(t *testing.T) {
+	ts := newTestServer(t)
+	routine := ts.createTask(t, alice, `{"title":"Stretch","type":"Routine","priority":2,"recurrence":{"freq":"daily"}}`)
+	today := *routine.NextOccurrence
+
+	// A bad field must reject the request before the occurrence is logged.
+	body := `{"completed":true,"due_at":"garbage"}`
+	if code := ts.do(t, alice, http.MethodPatch, taskPath(routine.ID), body, nil); code != http.StatusBadRequest {
+		t.Fatalf("UpdateTask = %d, want 400", code)
+	}
+	var events struct {
+		Events []json.RawMessage `json:"events"`
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/events?type=task_completed", "", &events)
+	if len(events.Events) != 0 {
+		t.Errorf("task_completed events = %d after a rejected request, want 0", len(events.Events))
+	}
+
+	// A new rule sent with the completion doesn't undo the roll-forward.
+	var resp struct {
+		NextOccurrence string `json:"next_occurrence"`
+	}
+	body = `{"completed":true,"recurrence":{"freq":"weekdays"}}`
+	if code := ts.do(t, alice, http.MethodPatch, taskPath(routine.ID), body, &resp); code != http.StatusOK {
+		t.Fatalf("UpdateTask = %d, want 200", code)
+	}
+	if resp.NextOccurrence <= today {
+		t.Errorf("next_occurrence = %s, want after %s", resp.NextOccurrence, today)
+	}
+	var list struct {
+		Tasks []models.Task `json:"tasks"`
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/tasks?type=Routine", "", &list)
+	if len(list.Tasks) != 1 || list.Tasks[0].NextOccurrence == nil || *list.Tasks[0].NextOccurrence != resp.NextOccurrence {
+		t.Errorf("routine = %+v, want next_occurrence %s", list.Tasks, resp.NextOccurrence)
+	}
+}
+
+func sessionBody(start time.Time, d time.Duration, cycles int) string {
+	return `{"started_at":"` + start.Format(time.RFC3339) + `","ended_at":"` + start.Add(d).Format(time.RFC3339) +
+		`","cycles":` + strconv.Itoa(cycles) + `}`
+}
+
+func TestBreathingSessions(t *testing.T) {
+	ts := newTestServer(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var first, second models.BreathingSession
+	code := ts.do(t, alice, http.MethodPost, "/api/v1/breathing/sessions", sessionBody(now.Add(-2*time.Hour), 5*time.Minute, 4), &first)
+	if code != http.StatusCreated || first.ID == 0 || first.Cycles != 4 {
+		t.Fatalf("CreateBreathingSession = %d %+v", code, first)
+	}
+	ts.do(t, alice, http.MethodPost, "/api/v1/breathing/sessions", sessionBody(now.Add(-time.Hour), time.Minute, 1), &second)
+	ts.do(t, bob, http.MethodPost, "/api/v1/breathing/sessions", sessionBody(now.Add(-time.Hour), time.Minute, 1), nil)
+
+	var resp struct {
+		Sessions []models.BreathingSession `json:"sessions"`
+	}
+	if code := ts.do(t, alice, http.MethodGet, "/api/v1/breathing/sessions", "", &resp); code != http.StatusOK {
+		t.Fatalf("GetBreathingSessions = %d, want 200", code)
+	}
+	if len(resp.Sessions) != 2 || resp.Sessions[0].ID != second.ID || resp.Sessions[1].ID != first.ID {
+		t.Errorf("sessions = %+v, want newest first", resp.Sessions)
+	}
+	ts.do(t, alice, http.MethodGet, "/api/v1/breathing/sessions?limit=1&offset=1", "", &resp)
+	if len(resp.Sessions) != 1 || resp.Sessions[0].ID != first.ID {
+		t.Errorf("second page = %+v", resp.Sessions)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"ends before it starts", sessionBody(now, -time.Minute, 1)},
+		{"longer than an hour", sessionBody(now.Add(-3*time.Hour), 2*time.Hour, 1)},
+		{"in the future", sessionBody(now.Add(time.Hour), time.Minute, 1)},
+		{"too many cycles", sessionBody(now.Add(-time.Hour), time.Minute, 101)},
+		{"missing times", `{"cycles":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ts.do(t, alice, http.MethodPost, "/api/v1/breathing/sessions", tt.body, nil); code != http.StatusBadRequest {
+				t.Errorf("CreateBreathingSession = %d, want 400", code)
+			}
+		})
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	ts := newTestServer(t)
+	done := ts.createTask(t, alice, `{"title":"Done","type":"Task","priority":1}`)
+	ts.createTask(t, alice, `{"title":"Open","type":"Task","priority":2}`)
+	ts.do(t, alice, http.MethodPatch, taskPath(done.ID), `{"completed":true}`, nil)
+	ts.createTask(t, bob, `{"title":"Bob's","type":"Task","priority":1}`)
+	now := time.Now().UTC().Truncate(time.Second)
+	ts.do(t, alice, http.MethodPost, "/api/v1/breathing/sessions", sessionBody(now.Add(-10*time.Minute), 5*time.Minute, 4), nil)
+
+	var got stats.Stats
+	if code := ts.do(t, alice, http.MethodGet, "/api/v1/stats", "", &got); code != http.StatusOK {
+		t.Fatalf("GetStats = %d, want 200", code)
+	}
+	if got.Timezone != "UTC" || len(got.PerDay) != stats.DefaultDays {
+		t.Errorf("timezone, days = %s, %d", got.Timezone, len(got.PerDay))
+	}
+	if last := got.PerDay[len(got.PerDay)-1]; last.Count != 1 {
+		t.Errorf("today's completions = %d, want 1", last.Count)
+	}
+	want := []stats.CompletionRate{{Key: "Task", Total: 2, Completed: 1, Rate: 0.5}}
+	if !reflect.DeepEqual(got.RateByType, want) {
+		t.Errorf("RateByType = %+v, want %+v", got.RateByType, want)
+	}
+	if got.CurrentStreak != 1 || got.Breathing.Sessions != 1 || got.Breathing.CurrentStreak != 1 {
+		t.Errorf("streak %d, breathing %+v", got.CurrentStreak, got.Breathing)
+	}
+
+	ts.do(t, alice, http.MethodGet, "/api/v1/stats?from=2026-01-01&to=2026-01-07", "", &got)
+	if got.From != "2026-01-01" || len(got.PerDay) != 7 {
+		t.Errorf("custom range = %s..%s, %d days", got.From, got.To, len(got.PerDay))
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"bad from", "?from=yesterday"},
+		{"bad to", "?to=2026-13-01"},
+		{"from after to", "?from=2026-02-01&to=2026-01-01"},
+		{"range too large", "?from=2024-01-01&to=2026-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ts.do(t, alice, http.MethodGet, "/api/v1/stats"+tt.query, "", nil); code != http.StatusBadRequest {
+				t.Errorf("GetStats = %d, want 400", code)
+			}
+		})
+	}
+}
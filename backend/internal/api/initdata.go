@@ -0,0 +1,153 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultInitDataMaxAge = 24 * time.Hour
+	// initDataClockSkew tolerates auth_date slightly ahead of our clock.
+	initDataClockSkew = time.Minute
+)
+
+// telegramPublicKey verifies the Ed25519 "signature" field Telegram adds to
+// initData for third-party validation (production environment).
+var telegramPublicKey = ed25519.PublicKey(mustDecodeHex("e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d"))
+
+// InitDataValidator checks Mini App initData. Keys are derived once, at
+// construction.
+type InitDataValidator struct {
+	secretKey []byte // HMAC-SHA256("WebAppData", token); nil without a token
+	botID     string
+	maxAge    time.Duration
+	publicKey ed25519.PublicKey
+}
+
+// NewInitDataValidator builds a validator for botToken. The "hash" field is
+// checked with the token; the Ed25519 "signature" field only needs the bot
+// ID, taken from botID or the token's prefix. maxAge <= 0 disables the
+// auth_date expiry check.
+func NewInitDataValidator(botToken, botID string, maxAge time.Duration) *InitDataValidator {
+	v := &InitDataValidator{botID: botID, maxAge: maxAge, publicKey: telegramPublicKey}
+	if botToken != "" {
+		mac := hmac.New(sha256.New, []byte("WebAppData"))
+		mac.Write([]byte(botToken))
+		v.secretKey = mac.Sum(nil)
+		if v.botID == "" {
+			v.botID, _, _ = strings.Cut(botToken, ":")
+		}
+	}
+	return v
+}
+
+func (v *InitDataValidator) validateInitData(initData string) (*TelegramUser, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, errors.New("invalid init data format")
+	}
+
+	hash := values.Get("hash")
+	signature := values.Get("signature")
+	if hash == "" && signature == "" {
+		return nil, errors.New("missing hash")
+	}
+
+	switch {
+	case hash != "" && v.secretKey != nil:
+		if !v.checkHash(values, hash) {
+			return nil, errors.New("invalid signature")
+		}
+	case signature != "" && v.botID != "":
+		if !v.checkSignature(values, signature) {
+			return nil, errors.New("invalid signature")
+		}
+	default:
+		return nil, errors.New("invalid signature")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, errors.New("missing auth_date")
+	}
+	if v.maxAge > 0 {
+		age := time.Since(time.Unix(authDate, 0))
+		if age > v.maxAge || age < -initDataClockSkew {
+			return nil, errors.New("init data expired")
+		}
+	}
+
+	userJSON := values.Get("user")
+	if userJSON == "" {
+		return nil, errors.New("missing user data")
+	}
+
+	var user TelegramUser
+	if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
+		return nil, errors.New("invalid user data")
+	}
+
+	return &user, nil
+}
+
+// checkHash verifies the bot-token HMAC over every field except hash.
+func (v *InitDataValidator) checkHash(values url.Values, hash string) bool {
+	expected, err := hex.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	h := hmac.New(sha256.New, v.secretKey)
+	h.Write([]byte(dataCheckString(values, "hash")))
+	return hmac.Equal(h.Sum(nil), expected)
+}
+
+// checkSignature verifies Telegram's Ed25519 signature over
+// "<bot_id>:WebAppData\n" and every field except hash and signature.
+func (v *InitDataValidator) checkSignature(values url.Values, signature string) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(signature, "="))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	msg := v.botID + ":WebAppData\n" + dataCheckString(values, "hash", "signature")
+	return ed25519.Verify(v.publicKey, []byte(msg), sig)
+}
+
+func dataCheckString(values url.Values, exclude ...string) string {
+	var keys []string
+	for k := range values {
+		skip := false
+		for _, e := range exclude {
+			if k == e {
+				skip = true
+			}
+		}
+		if !skip {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+values.Get(k))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
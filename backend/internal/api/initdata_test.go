@@ -0,0 +1,176 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:TEST-TOKEN"
+
+const testUserJSON = `{"id":42,"first_name":"Ann","username":"ann"}`
+
+func newTestValidator(t *testing.T) (*InitDataValidator, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewInitDataValidator(testBotToken, "", time.Hour)
+	v.publicKey = pub
+	return v, priv
+}
+
+func initDataFields(authDate time.Time) url.Values {
+	return url.Values{
+		"auth_date": {strconv.FormatInt(authDate.Unix(), 10)},
+		"query_id":  {"AAE"},
+		"user":      {testUserJSON},
+	}
+}
+
+// signHash adds the bot-token HMAC the way Telegram computes "hash".
+func signHash(values url.Values) string {
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(testBotToken))
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(dataCheckString(values, "hash")))
+	values.Set("hash", hex.EncodeToString(h.Sum(nil)))
+	return values.Encode()
+}
+
+// signEd25519 adds the third-party "signature" field with priv.
+func signEd25519(values url.Values, priv ed25519.PrivateKey) string {
+	msg := "123456:WebAppData\n" + dataCheckString(values, "hash", "signature")
+	values.Set("signature", base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, []byte(msg))))
+	return values.Encode()
+}
+
+func TestValidateInitData(t *testing.T) {
+	v, priv := newTestValidator(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		initData func() string
+		wantErr  string
+	}{
+		{
+			name:     "valid hash",
+			initData: func() string { return signHash(initDataFields(now)) },
+		},
+		{
+			name:     "valid signature",
+			initData: func() string { return signEd25519(initDataFields(now), priv) },
+		},
+		{
+			name: "hash mismatch",
+			initData: func() string {
+				values := initDataFields(now)
+				signHash(values)
+				values.Set("user", `{"id":43,"first_name":"Eve"}`)
+				return values.Encode()
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name: "malformed hash",
+			initData: func() string {
+				values := initDataFields(now)
+				values.Set("hash", "not-hex")
+				return values.Encode()
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name:     "signature from another key",
+			initData: func() string { return signEd25519(initDataFields(now), otherKey) },
+			wantErr:  "invalid signature",
+		},
+		{
+			name: "truncated signature",
+			initData: func() string {
+				values := initDataFields(now)
+				values.Set("signature", base64.RawURLEncoding.EncodeToString([]byte("short")))
+				return values.Encode()
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name:     "missing hash",
+			initData: func() string { return initDataFields(now).Encode() },
+			wantErr:  "missing hash",
+		},
+		{
+			name:     "expired auth_date",
+			initData: func() string { return signHash(initDataFields(now.Add(-2 * time.Hour))) },
+			wantErr:  "init data expired",
+		},
+		{
+			name:     "future auth_date within skew",
+			initData: func() string { return signHash(initDataFields(now.Add(initDataClockSkew / 2))) },
+		},
+		{
+			name:     "future auth_date beyond skew",
+			initData: func() string { return signHash(initDataFields(now.Add(2 * initDataClockSkew))) },
+			wantErr:  "init data expired",
+		},
+		{
+			name: "missing auth_date",
+			initData: func() string {
+				values := initDataFields(now)
+				values.Del("auth_date")
+				return signHash(values)
+			},
+			wantErr: "missing auth_date",
+		},
+		{
+			name: "missing user",
+			initData: func() string {
+				values := initDataFields(now)
+				values.Del("user")
+				return signHash(values)
+			},
+			wantErr: "missing user data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := v.validateInitData(tt.initData())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateInitData: %v", err)
+			}
+			if user.ID != 42 || user.FirstName != "Ann" {
+				t.Errorf("user = %+v", user)
+			}
+		})
+	}
+}
+
+func TestValidateInitDataWithoutToken(t *testing.T) {
+	v, priv := newTestValidator(t)
+	v.secretKey = nil
+
+	if _, err := v.validateInitData(signHash(initDataFields(time.Now()))); err == nil {
+		t.Error("hash accepted without a bot token")
+	}
+	if _, err := v.validateInitData(signEd25519(initDataFields(time.Now()), priv)); err != nil {
+		t.Errorf("signature rejected without a bot token: %v", err)
+	}
+}
@@ -1,14 +1,7 @@
 package api
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
-	"errors"
-	"net/url"
-	"os"
-	"sort"
+	"log"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -22,7 +15,8 @@ type TelegramUser struct {
 	Language  string `json:"language_code"`
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates Mini App requests by their initData.
+func AuthMiddleware(v *InitDataValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if !strings.HasPrefix(auth, "tma ") {
@@ -31,7 +25,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		initData := auth[4:]
-		user, err := validateInitData(initData)
+		user, err := v.validateInitData(initData)
 		if err != nil {
 			c.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
 			return
@@ -42,54 +36,18 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-func validateInitData(initData string) (*TelegramUser, error) {
-	values, err := url.ParseQuery(initData)
-	if err != nil {
-		return nil, errors.New("invalid init data format")
-	}
-
-	hash := values.Get("hash")
-	if hash == "" {
-		return nil, errors.New("missing hash")
-	}
-
-	var keys []string
-	for k := range values {
-		if k != "hash" {
-			keys = append(keys, k)
+// UserMiddleware makes sure the authenticated user has a users row before
+// any handler writes rows that reference it.
+func (s *Server) UserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := s.Users.EnsureUser(c.Request.Context(), profileOf(GetUser(c)))
+		if err != nil {
+			log.Printf("EnsureUser error: %v", err)
+			c.AbortWithStatusJSON(500, gin.H{"error": "failed to load user"})
+			return
 		}
+		c.Next()
 	}
-	sort.Strings(keys)
-
-	var dataCheckParts []string
-	for _, k := range keys {
-		dataCheckParts = append(dataCheckParts, k+"="+values.Get(k))
-	}
-	dataCheckString := strings.Join(dataCheckParts, "\n")
-
-	botToken := os.Getenv("BOT_TOKEN")
-	secretKey := hmac.New(sha256.New, []byte("WebAppData"))
-	secretKey.Write([]byte(botToken))
-
-	h := hmac.New(sha256.New, secretKey.Sum(nil))
-	h.Write([]byte(dataCheckString))
-	calculatedHash := hex.EncodeToString(h.Sum(nil))
-
-	if calculatedHash != hash {
-		return nil, errors.New("invalid signature")
-	}
-
-	userJSON := values.Get("user")
-	if userJSON == "" {
-		return nil, errors.New("missing user data")
-	}
-
-	var user TelegramUser
-	if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
-		return nil, errors.New("invalid user data")
-	}
-
-	return &user, nil
 }
 
 func GetUser(c *gin.Context) *TelegramUser {
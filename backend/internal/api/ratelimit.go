@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gemini-backed endpoints are limited per user on top of the global IP limit.
+const (
+	aiRateLimit  = 20
+	aiRateWindow = time.Minute
+)
+
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{requests: make(map[string][]time.Time)}
+}
+
+func (rl *RateLimiter) Allow(key string, limit int, window time.Duration) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-window)
+
+	var valid []time.Time
+	for _, t := range rl.requests[key] {
+		if t.After(cutoff) {
+			valid = append(valid, t)
+		}
+	}
+
+	if len(valid) >= limit {
+		rl.requests[key] = valid
+		return false
+	}
+
+	rl.requests[key] = append(valid, now)
+	return true
+}
+
+var aiLimiter = NewRateLimiter()
+
+// AILimitMiddleware throttles AI parsing per authenticated user.
+func AILimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetUser(c)
+		if user != nil && !aiLimiter.Allow(strconv.FormatInt(user.ID, 10), aiRateLimit, aiRateWindow) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"os"
 
@@ -8,14 +9,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine) {
+func SetupRoutes(r *gin.Engine, s *Server, updates *bot.Dispatcher, auth *InitDataValidator) {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Telegram echoes the secret_token passed to setWebhook in the
+	// X-Telegram-Bot-Api-Secret-Token header.
+	secret := os.Getenv("WEBHOOK_SECRET")
 	r.POST("/bot/webhook", func(c *gin.Context) {
-		secret := os.Getenv("WEBHOOK_SECRET")
-		if secret != "" && c.Query("token") != secret {
+		got := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+		if secret != "" && subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
@@ -26,8 +30,10 @@ func SetupRoutes(r *gin.Engine) {
 			return
 		}
 
-		if err := bot.HandleWebhook(&update); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// A full queue makes Telegram retry later; duplicates are
+		// filtered by update_id when processed.
+		if !updates.Enqueue(update) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "busy"})
 			return
 		}
 
@@ -35,15 +41,26 @@ func SetupRoutes(r *gin.Engine) {
 	})
 
 	api := r.Group("/api/v1")
-	api.Use(AuthMiddleware())
+	api.Use(AuthMiddleware(auth), s.UserMiddleware())
 	{
-		api.GET("/tasks", GetTasks)
-		api.POST("/tasks", CreateTask)
-		api.POST("/tasks/audio", CreateTaskFromAudio)
-		api.PATCH("/tasks/:id", UpdateTask)
-		api.DELETE("/tasks/:id", DeleteTask)
-
-		api.GET("/user/preferences", GetPreferences)
-		api.PATCH("/user/preferences", UpdatePreferences)
+		api.GET("/tasks", s.GetTasks)
+		api.POST("/tasks", s.CreateTask)
+		api.POST("/tasks/audio", AILimitMiddleware(), s.CreateTaskFromAudio)
+		api.POST("/tasks/parse", AILimitMiddleware(), s.CreateTasksFromText)
+		api.POST("/tasks/reorder", s.ReorderTasks)
+		api.PATCH("/tasks/:id", s.UpdateTask)
+		api.DELETE("/tasks/:id", s.DeleteTask)
+		api.GET("/tasks/:id/subtasks", s.GetSubtasks)
+		api.POST("/tasks/:id/subtasks", s.CreateSubtask)
+		api.POST("/tasks/:id/subtasks/reorder", s.ReorderSubtasks)
+
+		api.GET("/events", s.GetEvents)
+		api.GET("/stats", s.GetStats)
+
+		api.POST("/breathing/sessions", s.CreateBreathingSession)
+		api.GET("/breathing/sessions", s.GetBreathingSessions)
+
+		api.GET("/user/preferences", s.GetPreferences)
+		api.PATCH("/user/preferences", s.UpdatePreferences)
 	}
 }
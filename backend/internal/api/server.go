@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/store"
+)
+
+// Server carries the dependencies of the API handlers, so they can run
+// against the in-memory store.
+type Server struct {
+	Tasks    store.TaskStore
+	Users    store.UserStore
+	Activity store.ActivityStore
+	Record   events.Recorder
+}
+
+func NewServer(tasks store.TaskStore, users store.UserStore, activity store.ActivityStore) *Server {
+	return &Server{Tasks: tasks, Users: users, Activity: activity, Record: activity.RecordEvent}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/stats"
+	"github.com/enkinvsh/focus-backend/internal/timezone"
+	"github.com/gin-gonic/gin"
+)
+
+// GetStats aggregates the user's completions over [from, to] (inclusive
+// local dates, default the last 30 days), bucketed in their timezone.
+func (s *Server) GetStats(c *gin.Context) {
+	user := GetUser(c)
+	ctx := c.Request.Context()
+	loc := s.Users.Location(ctx, user.ID)
+	today := timezone.Today(loc)
+
+	to := today
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to (use YYYY-MM-DD)"})
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(stats.DefaultDays - 1))
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from (use YYYY-MM-DD)"})
+			return
+		}
+		from = t
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	if to.Sub(from) > stats.MaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range too large (max 366 days)"})
+		return
+	}
+
+	result, err := s.Activity.Stats(ctx, user.ID, loc, from, to, today)
+	if err != nil {
+		log.Printf("GetStats error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/enkinvsh/focus-backend/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const maxSubtasks = 50
+
+func (s *Server) GetSubtasks(c *gin.Context) {
+	user := GetUser(c)
+	parentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	subtasks, err := s.Tasks.ListSubtasks(c.Request.Context(), user.ID, parentID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("GetSubtasks error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch subtasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subtasks": subtasks})
+}
+
+func (s *Server) CreateSubtask(c *gin.Context) {
+	user := GetUser(c)
+	parentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req models.CreateSubtaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title required"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	parent, err := s.Tasks.GetTask(ctx, user.ID, parentID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("CreateSubtask error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create subtask"})
+		return
+	}
+	if parent.TaskType != "Long" || parent.ParentID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subtasks are only supported for Long tasks"})
+		return
+	}
+	if parent.SubtasksTotal >= maxSubtasks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many subtasks (max 50)"})
+		return
+	}
+
+	task := models.Task{
+		UserID:   user.ID,
+		ParentID: &parent.ID,
+		Title:    req.Title,
+		TaskType: parent.TaskType,
+		Priority: parent.Priority,
+	}
+	err = s.Tasks.CreateSubtask(ctx, &task)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("CreateSubtask error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create subtask"})
+		return
+	}
+
+	s.Record(ctx, user.ID, events.TaskCreated, gin.H{
+		"task_id": task.ID, "parent_id": parent.ID, "title": task.Title, "type": task.TaskType, "source": events.SourceText,
+	})
+
+	c.JSON(http.StatusCreated, task)
+}
+
+func (s *Server) ReorderSubtasks(c *gin.Context) {
+	user := GetUser(c)
+	parentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	var req models.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	err = s.Tasks.ReorderSubtasks(c.Request.Context(), user.ID, parentID, req.IDs)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	case errors.Is(err, store.ErrInvalidOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("ReorderSubtasks error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reorder subtasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,386 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAPIURL     = "https://api.telegram.org"
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	maxRetryAfter     = 60 * time.Second
+)
+
+// Client is a minimal typed Telegram Bot API client.
+type Client struct {
+	token      string
+	baseURL    string
+	http       *http.Client
+	maxRetries int
+}
+
+type ClientOption func(*Client)
+
+// WithBaseURL points the client at a local Bot API server or a test fake.
+func WithBaseURL(u string) ClientOption {
+	return func(c *Client) { c.baseURL = strings.TrimRight(u, "/") }
+}
+
+// WithTimeout sets the HTTP timeout. It copies the http.Client first, so a
+// client passed to WithHTTPClient is never changed.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		h := *c.http
+		h.Timeout = d
+		c.http = &h
+	}
+}
+
+func WithHTTPClient(h *http.Client) ClientOption {
+	return func(c *Client) { c.http = h }
+}
+
+// WithMaxRetries sets how many times a 429 response is retried.
+func WithMaxRetries(n int) ClientOption {
+	return func(c *Client) { c.maxRetries = n }
+}
+
+func NewClient(token string, opts ...ClientOption) *Client {
+	c := &Client{
+		token:      token,
+		baseURL:    DefaultAPIURL,
+		http:       &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is a Telegram response with "ok": false.
+type APIError struct {
+	Method      string
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram %s: %d %s", e.Method, e.Code, e.Description)
+}
+
+// Transient reports whether a failed call may succeed when retried: network
+// errors, 5xx and 429. Other 4xx responses, such as 403 from a user who
+// blocked the bot, will fail again.
+func Transient(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500 || apiErr.Code < 400
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters,omitempty"`
+}
+
+// Call invokes a Bot API method with a JSON body and decodes "result" into
+// result (which may be nil). 429 responses are retried after retry_after.
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.do(ctx, method, body, result)
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests || attempt >= c.maxRetries {
+			return err
+		}
+
+		wait := apiErr.RetryAfter
+		if wait <= 0 {
+			wait = time.Second
+		}
+		if wait > maxRetryAfter {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method string, body []byte, result interface{}) error {
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Strip the URL so the bot token never ends up in logs.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("telegram %s: status %d: %w", method, resp.StatusCode, err)
+	}
+
+	if !apiResp.OK {
+		apiErr := &APIError{Method: method, Code: apiResp.ErrorCode, Description: apiResp.Description}
+		if apiErr.Code == 0 {
+			apiErr.Code = resp.StatusCode
+		}
+		if apiResp.Parameters != nil {
+			apiErr.RetryAfter = time.Duration(apiResp.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
+	}
+
+	if result != nil && len(apiResp.Result) > 0 {
+		return json.Unmarshal(apiResp.Result, result)
+	}
+	return nil
+}
+
+// markup returns nil for an empty keyboard so reply_markup is omitted.
+func markup(keyboard InlineKeyboard) *InlineKeyboard {
+	if len(keyboard.InlineKeyboard) == 0 {
+		return nil
+	}
+	return &keyboard
+}
+
+type sendMessageParams struct {
+	ChatID      int64           `json:"chat_id"`
+	Text        string          `json:"text"`
+	ParseMode   string          `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+type sendPhotoParams struct {
+	ChatID      int64           `json:"chat_id"`
+	Photo       string          `json:"photo"`
+	Caption     string          `json:"caption,omitempty"`
+	ParseMode   string          `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+type editMessageParams struct {
+	ChatID      int64           `json:"chat_id"`
+	MessageID   int64           `json:"message_id"`
+	Text        string          `json:"text,omitempty"`
+	ParseMode   string          `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string, keyboard InlineKeyboard) (*Message, error) {
+	var msg Message
+	err := c.Call(ctx, "sendMessage", sendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ParseMode:   "HTML",
+		ReplyMarkup: markup(keyboard),
+	}, &msg)
+	return &msg, err
+}
+
+func (c *Client) SendPhoto(ctx context.Context, chatID int64, photoURL, caption string, keyboard InlineKeyboard) (*Message, error) {
+	var msg Message
+	err := c.Call(ctx, "sendPhoto", sendPhotoParams{
+		ChatID:      chatID,
+		Photo:       photoURL,
+		Caption:     caption,
+		ParseMode:   "HTML",
+		ReplyMarkup: markup(keyboard),
+	}, &msg)
+	return &msg, err
+}
+
+// EditMessageText replaces a message's text. An empty keyboard removes the
+// existing inline keyboard.
+func (c *Client) EditMessageText(ctx context.Context, chatID, messageID int64, text string, keyboard InlineKeyboard) error {
+	return c.Call(ctx, "editMessageText", editMessageParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ParseMode:   "HTML",
+		ReplyMarkup: markup(keyboard),
+	}, nil)
+}
+
+// EditMessageReplyMarkup swaps the inline keyboard; an empty one removes it.
+func (c *Client) EditMessageReplyMarkup(ctx context.Context, chatID, messageID int64, keyboard InlineKeyboard) error {
+	if keyboard.InlineKeyboard == nil {
+		keyboard.InlineKeyboard = [][]InlineButton{}
+	}
+	return c.Call(ctx, "editMessageReplyMarkup", editMessageParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		ReplyMarkup: &keyboard,
+	}, nil)
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, chatID, messageID int64) error {
+	return c.Call(ctx, "deleteMessage", map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}, nil)
+}
+
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackID, text string) error {
+	params := map[string]interface{}{"callback_query_id": callbackID}
+	if text != "" {
+		params["text"] = text
+	}
+	return c.Call(ctx, "answerCallbackQuery", params, nil)
+}
+
+func (c *Client) GetFile(ctx context.Context, fileID string) (*File, error) {
+	var f File
+	err := c.Call(ctx, "getFile", map[string]interface{}{"file_id": fileID}, &f)
+	return &f, err
+}
+
+// DownloadFile fetches a file returned by GetFile, refusing bodies larger
+// than maxSize bytes.
+func (c *Client) DownloadFile(ctx context.Context, filePath string, maxSize int64) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/file/bot%s/%s", c.baseURL, c.token, filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// As in do, drop the URL: it carries the bot token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("telegram file download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("telegram file download: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.New("file too large")
+	}
+	return data, nil
+}
+
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// SetMyCommands registers the command menu; an empty languageCode sets the
+// default list.
+func (c *Client) SetMyCommands(ctx context.Context, commands []BotCommand, languageCode string) error {
+	params := map[string]interface{}{"commands": commands}
+	if languageCode != "" {
+		params["language_code"] = languageCode
+	}
+	return c.Call(ctx, "setMyCommands", params, nil)
+}
+
+type WebhookParams struct {
+	URL            string   `json:"url"`
+	SecretToken    string   `json:"secret_token,omitempty"`
+	AllowedUpdates []string `json:"allowed_updates,omitempty"`
+	DropPending    bool     `json:"drop_pending_updates,omitempty"`
+}
+
+func (c *Client) SetWebhook(ctx context.Context, params WebhookParams) error {
+	return c.Call(ctx, "setWebhook", params, nil)
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	return c.Call(ctx, "deleteWebhook", map[string]interface{}{}, nil)
+}
+
+type getUpdatesParams struct {
+	Offset         int64    `json:"offset,omitempty"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates,omitempty"`
+}
+
+// GetUpdates long-polls for up to timeout seconds. The client's HTTP
+// timeout must be longer than the poll timeout.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout int) ([]Update, error) {
+	var updates []Update
+	err := c.Call(ctx, "getUpdates", getUpdatesParams{
+		Offset:         offset,
+		Timeout:        timeout,
+		AllowedUpdates: []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+var (
+	clientMu      sync.Mutex
+	defaultClient *Client
+)
+
+// SetClient replaces the client used by the webhook handlers.
+func SetClient(c *Client) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	defaultClient = c
+}
+
+// api returns the shared client, creating one from BOT_TOKEN and
+// TELEGRAM_API_URL on first use.
+func api() *Client {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	if defaultClient == nil {
+		var opts []ClientOption
+		if u := os.Getenv("TELEGRAM_API_URL"); u != "" {
+			opts = append(opts, WithBaseURL(u))
+		}
+		defaultClient = NewClient(os.Getenv("BOT_TOKEN"), opts...)
+	}
+	return defaultClient
+}
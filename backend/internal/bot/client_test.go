@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAPI answers every request with the next response in replies and
+// records the method and decoded JSON body of each call.
+type fakeAPI struct {
+	t       *testing.T
+	replies []string
+	calls   []fakeCall
+	onCall  func()
+}
+
+type fakeCall struct {
+	Method string
+	Body   map[string]interface{}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		f.t.Errorf("decode body: %v", err)
+	}
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.calls = append(f.calls, fakeCall{Method: method, Body: body})
+
+	reply := `{"ok":true,"result":true}`
+	if len(f.replies) > 0 {
+		reply, f.replies = f.replies[0], f.replies[1:]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(reply))
+	if f.onCall != nil {
+		f.onCall()
+	}
+}
+
+func newFakeClient(t *testing.T, replies ...string) (*Client, *fakeAPI) {
+	f := &fakeAPI{t: t, replies: replies}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return NewClient("TOKEN", WithBaseURL(srv.URL+"/")), f
+}
+
+func TestCallAPIError(t *testing.T) {
+	c, _ := newFakeClient(t, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+
+	err := c.DeleteMessage(context.Background(), 1, 2)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	want := &APIError{Method: "deleteMessage", Code: 400, Description: "Bad Request: chat not found"}
+	if !reflect.DeepEqual(apiErr, want) {
+		t.Errorf("err = %+v, want %+v", apiErr, want)
+	}
+}
+
+func TestCallRetriesTooManyRequests(t *testing.T) {
+	c, f := newFakeClient(t,
+		`{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":1}}`,
+		`{"ok":true,"result":{"message_id":7,"chat":{"id":1}}}`,
+	)
+
+	msg, err := c.SendMessage(context.Background(), 1, "hi", InlineKeyboard{})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if msg.MessageID != 7 {
+		t.Errorf("MessageID = %d, want 7", msg.MessageID)
+	}
+	if len(f.calls) != 2 {
+		t.Errorf("calls = %d, want 2", len(f.calls))
+	}
+}
+
+func TestCallCancelledDuringBackoff(t *testing.T) {
+	c, f := newFakeClient(t, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":30}}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f.onCall = cancel
+
+	start := time.Now()
+	err := c.DeleteMessage(ctx, 1, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Call kept waiting after the context was cancelled")
+	}
+	if len(f.calls) != 1 {
+		t.Errorf("calls = %d, want 1", len(f.calls))
+	}
+}
+
+func TestRequestBodies(t *testing.T) {
+	ctx := context.Background()
+	keyboard := InlineKeyboard{InlineKeyboard: [][]InlineButton{{{Text: "Done", CallbackData: "done:1"}}}}
+
+	tests := []struct {
+		name  string
+		call  func(c *Client) error
+		reply string
+		body  map[string]interface{}
+	}{
+		{
+			name: "editMessageText",
+			call: func(c *Client) error { return c.EditMessageText(ctx, 1, 2, "<b>hi</b>", keyboard) },
+			body: map[string]interface{}{
+				"chat_id": 1.0, "message_id": 2.0, "text": "<b>hi</b>", "parse_mode": "HTML",
+				"reply_markup": map[string]interface{}{"inline_keyboard": []interface{}{[]interface{}{
+					map[string]interface{}{"text": "Done", "callback_data": "done:1"},
+				}}},
+			},
+		},
+		{
+			name: "editMessageText without keyboard",
+			call: func(c *Client) error { return c.EditMessageText(ctx, 1, 2, "hi", InlineKeyboard{}) },
+			body: map[string]interface{}{"chat_id": 1.0, "message_id": 2.0, "text": "hi", "parse_mode": "HTML"},
+		},
+		{
+			name: "deleteMessage",
+			call: func(c *Client) error { return c.DeleteMessage(ctx, 1, 2) },
+			body: map[string]interface{}{"chat_id": 1.0, "message_id": 2.0},
+		},
+		{
+			name: "getFile",
+			call: func(c *Client) error {
+				f, err := c.GetFile(ctx, "abc")
+				if err == nil && f.FilePath != "voice/file_1.oga" {
+					t.Errorf("FilePath = %q, want voice/file_1.oga", f.FilePath)
+				}
+				return err
+			},
+			reply: `{"ok":true,"result":{"file_id":"abc","file_path":"voice/file_1.oga"}}`,
+			body:  map[string]interface{}{"file_id": "abc"},
+		},
+		{
+			name: "setMyCommands",
+			call: func(c *Client) error {
+				return c.SetMyCommands(ctx, []BotCommand{{Command: "today", Description: "Today"}}, "ru")
+			},
+			body: map[string]interface{}{
+				"commands":      []interface{}{map[string]interface{}{"command": "today", "description": "Today"}},
+				"language_code": "ru",
+			},
+		},
+		{
+			name: "setWebhook",
+			call: func(c *Client) error {
+				return c.SetWebhook(ctx, WebhookParams{URL: "https://example.com/bot/webhook", SecretToken: "s3cret"})
+			},
+			body: map[string]interface{}{"url": "https://example.com/bot/webhook", "secret_token": "s3cret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replies []string
+			if tt.reply != "" {
+				replies = append(replies, tt.reply)
+			}
+			c, f := newFakeClient(t, replies...)
+			if err := tt.call(c); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if len(f.calls) != 1 {
+				t.Fatalf("calls = %d, want 1", len(f.calls))
+			}
+			method := strings.Fields(tt.name)[0]
+			if f.calls[0].Method != method {
+				t.Errorf("method = %q, want %q", f.calls[0].Method, method)
+			}
+			if !reflect.DeepEqual(f.calls[0].Body, tt.body) {
+				t.Errorf("body = %v, want %v", f.calls[0].Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestDownloadFileError(t *testing.T) {
+	c, _ := newFakeClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.DownloadFile(ctx, "voice/file_1.oga", 1024)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "TOKEN") {
+		t.Errorf("err = %q leaks the bot token", err)
+	}
+}
+
+func TestWithTimeoutCopiesHTTPClient(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+	c := NewClient("TOKEN", WithHTTPClient(shared), WithTimeout(time.Second))
+
+	if shared.Timeout != time.Minute {
+		t.Errorf("shared client timeout = %v, want it unchanged", shared.Timeout)
+	}
+	if c.http.Timeout != time.Second {
+		t.Errorf("client timeout = %v, want 1s", c.http.Timeout)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", errors.New("telegram sendMessage: connection reset"), true},
+		{"server error", &APIError{Code: 502}, true},
+		{"rate limited", &APIError{Code: 429}, true},
+		{"blocked", &APIError{Code: 403}, false},
+		{"bad request", &APIError{Code: 400}, false},
+	}
+	for _, tt := range tests {
+		if got := Transient(tt.err); got != tt.want {
+			t.Errorf("%s: Transient = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
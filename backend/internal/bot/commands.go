@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/recurrence"
+	"github.com/enkinvsh/focus-backend/internal/timezone"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	listLimit  = 20
+	cbComplete = "cmp:"
+)
+
+// Views that a task list message can be re-rendered as after a tap.
+const (
+	viewToday = "today"
+	viewDone  = "done"
+	viewList  = "list-" // suffixed with the task type
+)
+
+type listItem struct {
+	ID       int64
+	Title    string
+	Priority int
+	DueAt    *time.Time
+}
+
+// parseCommand splits "/list@FocusBot long" into ("/list", "long").
+func parseCommand(text string) (string, string) {
+	cmd, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+	if at := strings.Index(cmd, "@"); at != -1 {
+		cmd = cmd[:at]
+	}
+	return strings.ToLower(cmd), strings.TrimSpace(args)
+}
+
+// listViewFor maps the /list argument to a view; unknown values fall back to Task.
+func listViewFor(arg string) string {
+	switch strings.ToLower(arg) {
+	case "long":
+		return viewList + "Long"
+	case "routine":
+		return viewList + "Routine"
+	default:
+		return viewList + "Task"
+	}
+}
+
+func sendTaskList(ctx context.Context, chatID, userID int64, view string, t Texts) error {
+	text, keyboard, err := renderTaskList(ctx, userID, view, t)
+	if err != nil {
+		return err
+	}
+	return sendMessage(ctx, chatID, text, keyboard)
+}
+
+func renderTaskList(ctx context.Context, userID int64, view string, t Texts) (string, InlineKeyboard, error) {
+	loc := db.UserLocation(ctx, db.Pool, userID)
+
+	var header, empty string
+	var query string
+	var args []interface{}
+
+	switch {
+	case view == viewToday:
+		tomorrow := timezone.Today(loc).AddDate(0, 0, 1)
+		header, empty = t.TodayTitle, t.NothingToday
+		query = `
+			SELECT id, title, priority, due_at FROM tasks
+			WHERE user_id = $1 AND NOT completed AND archived_at IS NULL AND parent_id IS NULL
+				AND (due_at < $2 OR (due_at IS NULL AND priority = 1)
+					OR next_occurrence < ($2 AT TIME ZONE $4)::date)
+			ORDER BY due_at NULLS LAST, priority ASC, created_at DESC
+			LIMIT $3`
+		args = []interface{}{userID, tomorrow, listLimit, loc.String()}
+	case view == viewDone:
+		header, empty = t.DoneTitle, t.ListEmpty
+		query = `
+			SELECT id, title, priority, due_at FROM tasks
+			WHERE user_id = $1 AND NOT completed AND archived_at IS NULL AND parent_id IS NULL
+			ORDER BY priority ASC, created_at DESC
+			LIMIT $2`
+		args = []interface{}{userID, listLimit}
+	case strings.HasPrefix(view, viewList):
+		taskType := strings.TrimPrefix(view, viewList)
+		header, empty = fmt.Sprintf(t.ListTitle, typeLabel(taskType, t)), t.ListEmpty
+		query = `
+			SELECT id, title, priority, due_at FROM tasks
+			WHERE user_id = $1 AND task_type = $2 AND NOT completed AND archived_at IS NULL AND parent_id IS NULL
+			ORDER BY priority ASC, created_at DESC
+			LIMIT $3`
+		args = []interface{}{userID, taskType, listLimit}
+	default:
+		return "", InlineKeyboard{}, fmt.Errorf("unknown list view %q", view)
+	}
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return "", InlineKeyboard{}, err
+	}
+	defer rows.Close()
+
+	var items []listItem
+	for rows.Next() {
+		var it listItem
+		if err := rows.Scan(&it.ID, &it.Title, &it.Priority, &it.DueAt); err != nil {
+			return "", InlineKeyboard{}, err
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return "", InlineKeyboard{}, err
+	}
+
+	if len(items) == 0 {
+		return header + "\n\n" + empty, InlineKeyboard{}, nil
+	}
+
+	lines := []string{header, ""}
+	var keyboard [][]InlineButton
+	for i, it := range items {
+		line := fmt.Sprintf("%d. %s %s", i+1, priorityMark(it.Priority), html.EscapeString(it.Title))
+		if it.DueAt != nil {
+			line += " <i>" + it.DueAt.In(loc).Format("02 Jan 15:04") + "</i>"
+		}
+		lines = append(lines, line)
+		keyboard = append(keyboard, []InlineButton{{
+			Text:         fmt.Sprintf("✅ %d. %s", i+1, it.Title),
+			CallbackData: cbComplete + view + ":" + strconv.FormatInt(it.ID, 10),
+		}})
+	}
+
+	return strings.Join(lines, "\n"), InlineKeyboard{InlineKeyboard: keyboard}, nil
+}
+
+// handleCompleteCallback completes the tapped task and re-renders the list
+// in place.
+func handleCompleteCallback(ctx context.Context, cb *CallbackQuery, t Texts) error {
+	if cb.From == nil || cb.Message == nil {
+		return nil
+	}
+
+	view, rawID, ok := strings.Cut(strings.TrimPrefix(cb.Data, cbComplete), ":")
+	if !ok {
+		return nil
+	}
+	taskID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := completeTask(ctx, cb.From.ID, taskID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	text, keyboard, err := renderTaskList(ctx, cb.From.ID, view, t)
+	if err != nil {
+		return err
+	}
+	return api().EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, keyboard)
+}
+
+// completeTask completes a one-off task, or logs today's occurrence of a
+// routine and rolls it forward. pgx.ErrNoRows means the task is gone or was
+// already done.
+func completeTask(ctx context.Context, userID, taskID int64) (string, error) {
+	loc := db.UserLocation(ctx, db.Pool, userID)
+	var done *recurrence.Completion
+	err := pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+		var err error
+		done, err = recurrence.Complete(ctx, tx, userID, taskID, loc)
+		return err
+	})
+	if err == nil {
+		if done.AlreadyDone {
+			return done.Title, pgx.ErrNoRows
+		}
+		events.Record(ctx, userID, events.TaskCompleted, map[string]interface{}{
+			"task_id": taskID, "title": done.Title, "source": events.SourceBot,
+			"occurrence": done.Occurrence.Format(time.DateOnly),
+		})
+		return done.Title, nil
+	}
+	if !errors.Is(err, recurrence.ErrNotRecurring) {
+		return "", err
+	}
+
+	var title string
+	err = db.Pool.QueryRow(ctx, `
+		UPDATE tasks SET completed = TRUE, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND NOT completed
+		RETURNING title
+	`, taskID, userID).Scan(&title)
+	if err != nil {
+		return "", err
+	}
+	events.Record(ctx, userID, events.TaskCompleted, map[string]interface{}{
+		"task_id": taskID, "title": title, "source": events.SourceBot,
+	})
+	return title, nil
+}
+
+func priorityMark(priority int) string {
+	switch priority {
+	case 1:
+		return "🔴"
+	case 2:
+		return "🟡"
+	default:
+		return "⚪"
+	}
+}
+
+func typeLabel(taskType string, t Texts) string {
+	switch taskType {
+	case "Long":
+		return t.BtnTypeLong
+	case "Routine":
+		return t.BtnTypeRoutine
+	default:
+		return t.BtnTypeTask
+	}
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/timezone"
+)
+
+const digestSectionLimit = 10
+
+type Digest struct {
+	UserID   int64
+	Language string
+	Location *time.Location
+}
+
+// SendDigest sends the morning overview: today's priority-1 tasks, overdue
+// tasks and routines due today. Nothing is sent when all three are empty.
+func SendDigest(ctx context.Context, d Digest) error {
+	t := GetTexts(d.Language)
+	today := timezone.Today(d.Location)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	priority, err := digestTitles(ctx, `
+		SELECT title FROM tasks
+		WHERE user_id = $1 AND NOT completed AND archived_at IS NULL AND recurrence IS NULL AND parent_id IS NULL AND priority = 1
+			AND (due_at IS NULL OR (due_at >= $2 AND due_at < $3))
+		ORDER BY due_at NULLS LAST, created_at DESC
+		LIMIT $4
+	`, d.UserID, today, tomorrow, digestSectionLimit)
+	if err != nil {
+		return err
+	}
+	overdue, err := digestTitles(ctx, `
+		SELECT title FROM tasks
+		WHERE user_id = $1 AND NOT completed AND archived_at IS NULL AND recurrence IS NULL AND parent_id IS NULL AND due_at < $2
+		ORDER BY due_at
+		LIMIT $3
+	`, d.UserID, today, digestSectionLimit)
+	if err != nil {
+		return err
+	}
+	routines, err := digestTitles(ctx, `
+		SELECT title FROM tasks
+		WHERE user_id = $1 AND archived_at IS NULL AND recurrence IS NOT NULL AND next_occurrence <= $2::date
+		ORDER BY priority ASC, created_at
+		LIMIT $3
+	`, d.UserID, today.Format(time.DateOnly), digestSectionLimit)
+	if err != nil {
+		return err
+	}
+
+	if len(priority) == 0 && len(overdue) == 0 && len(routines) == 0 {
+		return nil
+	}
+
+	parts := []string{t.DigestTitle}
+	for _, section := range []struct {
+		header string
+		titles []string
+	}{
+		{t.DigestPriority, priority},
+		{t.DigestOverdue, overdue},
+		{t.DigestRoutines, routines},
+	} {
+		if len(section.titles) == 0 {
+			continue
+		}
+		lines := []string{section.header}
+		for _, title := range section.titles {
+			lines = append(lines, "• "+html.EscapeString(title))
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+
+	keyboard := InlineKeyboard{
+		InlineKeyboard: [][]InlineButton{
+			{{Text: t.BtnOpen, WebApp: &WebApp{URL: WebAppURL}}},
+		},
+	}
+	return sendMessage(ctx, d.UserID, strings.Join(parts, "\n\n"), keyboard)
+}
+
+func digestTitles(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("digest query: %w", err)
+	}
+	defer rows.Close()
+
+	var titles []string
+	for rows.Next() {
+		var title string
+		if err := rows.Scan(&title); err != nil {
+			return nil, err
+		}
+		titles = append(titles, title)
+	}
+	return titles, rows.Err()
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+)
+
+const updateTimeout = 60 * time.Second
+
+// Dispatcher processes updates on a bounded pool of workers so the webhook
+// can acknowledge Telegram immediately.
+type Dispatcher struct {
+	queue   chan Update
+	workers int
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewDispatcher(workers, queueSize int) *Dispatcher {
+	return &Dispatcher{
+		queue:   make(chan Update, queueSize),
+		workers: workers,
+	}
+}
+
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for update := range d.queue {
+				ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+				if err := Process(ctx, &update); err != nil {
+					log.Printf("Update %d error: %v", update.UpdateID, err)
+				}
+				cancel()
+			}
+		}()
+	}
+}
+
+// Enqueue reports false when the queue is full or stopped; the caller should
+// then let Telegram retry.
+func (d *Dispatcher) Enqueue(update Update) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return false
+	}
+	select {
+	case d.queue <- update:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop rejects new updates and waits for queued ones to finish.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// Process handles an update at most once. The update_id is claimed before
+// handling, so Telegram retries and redeliveries are acknowledged without
+// side effects. The claim is kept even when handling fails, since tasks may
+// already have been stored; replies retry their own send instead (see
+// sendMessage).
+func Process(ctx context.Context, update *Update) error {
+	tag, err := db.Pool.Exec(ctx, `
+		INSERT INTO processed_updates (update_id) VALUES ($1)
+		ON CONFLICT (update_id) DO NOTHING
+	`, update.UpdateID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Printf("Update %d already processed, skipping", update.UpdateID)
+		return nil
+	}
+	return HandleWebhook(ctx, update)
+}
@@ -1,17 +1,22 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"os"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/events"
 )
 
 const (
 	WebAppURL = "https://enkinvsh.github.io/focus/"
 	PhotoURL  = "https://raw.githubusercontent.com/enkinvsh/focus/main/enter.png"
 	GitHubURL = "https://github.com/enkinvsh/focus"
+
+	sendAttempts   = 3
+	sendRetryDelay = 2 * time.Second
 )
 
 type Update struct {
@@ -21,14 +26,17 @@ type Update struct {
 }
 
 type Message struct {
-	MessageID int64  `json:"message_id"`
-	Chat      Chat   `json:"chat"`
-	From      *User  `json:"from,omitempty"`
-	Text      string `json:"text,omitempty"`
+	MessageID   int64           `json:"message_id"`
+	Chat        Chat            `json:"chat"`
+	From        *User           `json:"from,omitempty"`
+	Text        string          `json:"text,omitempty"`
+	Voice       *Voice          `json:"voice,omitempty"`
+	ReplyMarkup *InlineKeyboard `json:"reply_markup,omitempty"`
 }
 
 type Chat struct {
-	ID int64 `json:"id"`
+	ID   int64  `json:"id"`
+	Type string `json:"type,omitempty"`
 }
 
 type User struct {
@@ -60,7 +68,12 @@ type WebApp struct {
 	URL string `json:"url"`
 }
 
-func HandleWebhook(update *Update) error {
+var knownCommands = map[string]bool{
+	"/start": true, "/help": true, "/about": true,
+	"/today": true, "/list": true, "/done": true, "/stats": true,
+}
+
+func HandleWebhook(ctx context.Context, update *Update) error {
 	var langCode string
 	if update.Message != nil && update.Message.From != nil {
 		langCode = update.Message.From.LanguageCode
@@ -70,22 +83,46 @@ func HandleWebhook(update *Update) error {
 	t := GetTexts(langCode)
 
 	if update.Message != nil {
-		return handleMessage(update.Message, t)
+		return handleMessage(ctx, update.Message, t)
 	}
 
 	if update.CallbackQuery != nil {
-		return handleCallback(update.CallbackQuery, t)
+		return handleCallback(ctx, update.CallbackQuery, t)
 	}
 
 	return nil
 }
 
-func handleMessage(msg *Message, t Texts) error {
+func handleMessage(ctx context.Context, msg *Message, t Texts) error {
 	chatID := msg.Chat.ID
 	text := msg.Text
 
-	switch text {
+	// Task capture only happens in the private chat with the bot.
+	if msg.Voice != nil && msg.Chat.Type == "private" {
+		return handleVoice(ctx, msg, t)
+	}
+
+	cmd, args := parseCommand(text)
+
+	// /start is usually the first thing a user sends, so the users row has
+	// to exist before the command event that references it is written.
+	if cmd == "/start" && msg.From != nil {
+		if err := ensureUser(ctx, msg.From); err != nil {
+			log.Printf("Bot ensureUser error: %v", err)
+		}
+	}
+
+	if msg.From != nil && knownCommands[cmd] {
+		events.Record(ctx, msg.From.ID, events.BotCommand, map[string]interface{}{"command": cmd})
+	}
+
+	switch cmd {
 	case "/start":
+		if msg.From != nil {
+			if err := unblockReminders(ctx, msg.From.ID); err != nil {
+				log.Printf("Bot unblock reminders error: %v", err)
+			}
+		}
 		caption := fmt.Sprintf("%s\n\n%s\n\n%s", t.Welcome, t.Features, t.CTA)
 		keyboard := InlineKeyboard{
 			InlineKeyboard: [][]InlineButton{
@@ -93,7 +130,8 @@ func handleMessage(msg *Message, t Texts) error {
 				{{Text: t.BtnBreathing, CallbackData: "breathing_info"}},
 			},
 		}
-		return sendPhoto(chatID, PhotoURL, caption, keyboard)
+		_, err := api().SendPhoto(ctx, chatID, PhotoURL, caption, keyboard)
+		return err
 
 	case "/help":
 		keyboard := InlineKeyboard{
@@ -101,7 +139,7 @@ func handleMessage(msg *Message, t Texts) error {
 				{{Text: t.BtnOpen, WebApp: &WebApp{URL: WebAppURL}}},
 			},
 		}
-		return sendMessage(chatID, t.Help, keyboard)
+		return sendMessage(ctx, chatID, t.Help, keyboard)
 
 	case "/about":
 		keyboard := InlineKeyboard{
@@ -109,15 +147,43 @@ func handleMessage(msg *Message, t Texts) error {
 				{{Text: "GitHub", URL: GitHubURL}},
 			},
 		}
-		return sendMessage(chatID, t.About, keyboard)
+		return sendMessage(ctx, chatID, t.About, keyboard)
+
+	case "/today":
+		if msg.From == nil {
+			return nil
+		}
+		return sendTaskList(ctx, chatID, msg.From.ID, viewToday, t)
+
+	case "/list":
+		if msg.From == nil {
+			return nil
+		}
+		return sendTaskList(ctx, chatID, msg.From.ID, listViewFor(args), t)
+
+	case "/done":
+		if msg.From == nil {
+			return nil
+		}
+		return sendTaskList(ctx, chatID, msg.From.ID, viewDone, t)
+
+	case "/stats":
+		if msg.From == nil {
+			return nil
+		}
+		return sendSummary(ctx, chatID, msg.From.ID, t)
+	}
+
+	if msg.Chat.Type == "private" && text != "" && !strings.HasPrefix(text, "/") {
+		return handleText(ctx, msg, t)
 	}
 
 	return nil
 }
 
-func handleCallback(cb *CallbackQuery, t Texts) error {
+func handleCallback(ctx context.Context, cb *CallbackQuery, t Texts) error {
 	// Answer callback to remove loading state
-	if err := answerCallback(cb.ID); err != nil {
+	if err := api().AnswerCallbackQuery(ctx, cb.ID, ""); err != nil {
 		return err
 	}
 
@@ -125,6 +191,22 @@ func handleCallback(cb *CallbackQuery, t Texts) error {
 		return nil
 	}
 
+	if isReminderCallback(cb.Data) {
+		return handleReminderCallback(ctx, cb, t)
+	}
+	if isReviewCallback(cb.Data) {
+		return handleReviewCallback(ctx, cb, t)
+	}
+	if strings.HasPrefix(cb.Data, cbUndo) {
+		return handleUndoCallback(ctx, cb)
+	}
+	if strings.HasPrefix(cb.Data, cbSetType) {
+		return handleSetTypeCallback(ctx, cb, t)
+	}
+	if strings.HasPrefix(cb.Data, cbComplete) {
+		return handleCompleteCallback(ctx, cb, t)
+	}
+
 	chatID := cb.Message.Chat.ID
 
 	switch cb.Data {
@@ -134,67 +216,24 @@ func handleCallback(cb *CallbackQuery, t Texts) error {
 				{{Text: t.BtnTry, WebApp: &WebApp{URL: WebAppURL}}},
 			},
 		}
-		return sendMessage(chatID, t.BreathingInfo, keyboard)
+		return sendMessage(ctx, chatID, t.BreathingInfo, keyboard)
 	}
 
 	return nil
 }
 
-func sendPhoto(chatID int64, photoURL, caption string, keyboard InlineKeyboard) error {
-	token := os.Getenv("BOT_TOKEN")
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendPhoto", token)
-
-	body := map[string]interface{}{
-		"chat_id":      chatID,
-		"photo":        photoURL,
-		"caption":      caption,
-		"parse_mode":   "HTML",
-		"reply_markup": keyboard,
-	}
-
-	return postJSON(url, body)
-}
-
-func sendMessage(chatID int64, text string, keyboard InlineKeyboard) error {
-	token := os.Getenv("BOT_TOKEN")
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
-
-	body := map[string]interface{}{
-		"chat_id":      chatID,
-		"text":         text,
-		"parse_mode":   "HTML",
-		"reply_markup": keyboard,
-	}
-
-	return postJSON(url, body)
-}
-
-func answerCallback(callbackID string) error {
-	token := os.Getenv("BOT_TOKEN")
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", token)
-
-	body := map[string]interface{}{
-		"callback_query_id": callbackID,
-	}
-
-	return postJSON(url, body)
-}
-
-func postJSON(url string, body map[string]interface{}) error {
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return err
-	}
-
-	resp, err := http.Post(url, "application/json", bytes.NewReader(jsonBody))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("telegram API error: %d", resp.StatusCode)
+// sendMessage retries transient failures itself. An update is never
+// replayed, because by the time it replies it may already have stored tasks.
+func sendMessage(ctx context.Context, chatID int64, text string, keyboard InlineKeyboard) error {
+	for attempt := 1; ; attempt++ {
+		_, err := api().SendMessage(ctx, chatID, text, keyboard)
+		if err == nil || attempt == sendAttempts || !Transient(err) {
+			return err
+		}
+		log.Printf("sendMessage attempt %d failed, retrying: %v", attempt, err)
+		sleepCtx(ctx, sendRetryDelay*time.Duration(attempt))
+		if ctx.Err() != nil {
+			return err
+		}
 	}
-
-	return nil
 }
@@ -11,6 +11,57 @@ type Texts struct {
 	BreathingInfo string
 	Help          string
 	About         string
+
+	ReminderTitle    string
+	ReminderDone     string
+	ReminderSnoozed  string
+	ReminderTomorrow string
+	ReminderGone     string
+	BtnDone          string
+	BtnSnooze        string
+	BtnTomorrow      string
+
+	TasksAdded    string
+	NoTasksFound  string
+	VoiceFailed   string
+	VoiceTooLarge string
+	BtnUndo       string
+
+	TextTooLong    string
+	BtnTypeTask    string
+	BtnTypeLong    string
+	BtnTypeRoutine string
+
+	TodayTitle   string
+	ListTitle    string
+	DoneTitle    string
+	NothingToday string
+	ListEmpty    string
+
+	CmdStart string
+	CmdToday string
+	CmdList  string
+	CmdDone  string
+	CmdHelp  string
+	CmdAbout string
+
+	CmdStats string
+	Summary  string
+
+	DigestTitle    string
+	DigestPriority string
+	DigestOverdue  string
+	DigestRoutines string
+
+	ReviewTitle         string
+	ReviewDone          string
+	ReviewStale         string
+	ReviewMore          string
+	BtnArchiveStale     string
+	BtnStaleUrgent      string
+	BtnStaleLater       string
+	ReviewArchived      string
+	ReviewReprioritized string
 }
 
 var I18n = map[string]Texts{
@@ -23,8 +74,59 @@ var I18n = map[string]Texts{
 		BtnOpen:       "🚀 Open App",
 		BtnTry:        "🎯 Try Now",
 		BreathingInfo: "🧘 <b>Breathing Exercise</b>\n\n1-minute technique to improve concentration:\n\n• Inhale (4 sec)\n• Hold (4 sec)\n• Exhale (4 sec)\n• 5 cycles\n\nTap the \"Focus\" title in the app to start.",
-		Help:          "📖 <b>Focus Guide</b>\n\n<b>How it works:</b>\n1. Tap «Launch Focus» button\n2. Record tasks by voice or text\n3. AI sorts them by category\n4. Swipe between tabs: Tasks / Long / Routine\n\n<b>Breathing Exercise:</b>\nTap on the «Focus» title in-app\n\n<b>Quick gestures:</b>\n• Swipe left/right — switch tabs\n• Tap a task — action menu",
+		Help:          "📖 <b>Focus Guide</b>\n\n<b>How it works:</b>\n1. Tap «Launch Focus» button\n2. Record tasks by voice or text\n3. AI sorts them by category\n4. Swipe between tabs: Tasks / Long / Routine\n\n<b>Breathing Exercise:</b>\nTap on the «Focus» title in-app\n\n<b>Quick gestures:</b>\n• Swipe left/right — switch tabs\n• Tap a task — action menu\n\n<b>Commands:</b>\n/today — due today and urgent\n/list [task|long|routine] — open tasks\n/done — tap to complete\n/stats — your week in numbers",
 		About:         "ℹ️ <b>About Focus</b>\n\n<b>Version:</b> 0.0.4\n\n<b>Technologies:</b>\n• PostgreSQL for data storage\n• Google Gemini AI for task processing\n• Go backend for API\n\n<b>Privacy:</b>\n• Data stored securely on our servers\n• No third-party accounts required\n• Secure API for all requests",
+
+		ReminderTitle:    "⏰ <b>Reminder</b>",
+		ReminderDone:     "✅ <b>Done</b>",
+		ReminderSnoozed:  "💤 <b>Snoozed for 1 hour</b>",
+		ReminderTomorrow: "📅 <b>Moved to tomorrow</b>",
+		ReminderGone:     "This task is no longer active.",
+		BtnDone:          "✅ Done",
+		BtnSnooze:        "💤 1h",
+		BtnTomorrow:      "📅 Tomorrow",
+
+		TasksAdded:    "✅ <b>Added tasks: %d</b>",
+		NoTasksFound:  "🤔 I couldn't find any tasks in that. Try again?",
+		VoiceFailed:   "⚠️ Couldn't process the voice message. Please try again.",
+		VoiceTooLarge: "⚠️ Voice message is too large (max 5MB).",
+		BtnUndo:       "↩️ Undo: %s",
+
+		TextTooLong:    "⚠️ That's too long (max 2000 characters).",
+		BtnTypeTask:    "Task",
+		BtnTypeLong:    "Long",
+		BtnTypeRoutine: "Routine",
+
+		TodayTitle:   "☀️ <b>Today</b>",
+		ListTitle:    "📋 <b>%s</b>",
+		DoneTitle:    "✅ <b>Tap a task to complete it</b>",
+		NothingToday: "Nothing due today. Enjoy the calm 🌿",
+		ListEmpty:    "No open tasks here 🎉",
+
+		CmdStart: "Launch Focus",
+		CmdToday: "Due today and urgent",
+		CmdList:  "Open tasks: task, long or routine",
+		CmdDone:  "Complete a task",
+		CmdHelp:  "How to use Focus",
+		CmdAbout: "About Focus",
+
+		CmdStats: "Your week in numbers",
+		Summary:  "📊 <b>Your last 7 days</b>\n\n<b>Tasks:</b>\n• Completed: %d\n• Streak: %d days\n\n<b>Breathing:</b>\n• Sessions: %d\n• Mindful minutes: %d\n• Streak: %d days",
+
+		DigestTitle:    "🌅 <b>Good morning!</b> Here's your day:",
+		DigestPriority: "🔴 <b>Priority</b>",
+		DigestOverdue:  "⏳ <b>Overdue</b>",
+		DigestRoutines: "🔁 <b>Routines</b>",
+
+		ReviewTitle:         "🗓 <b>Your week in review</b>",
+		ReviewDone:          "✅ <b>Done this week: %d</b>",
+		ReviewStale:         "🕸 <b>Untouched for %d+ days: %d</b>",
+		ReviewMore:          "…and %d more",
+		BtnArchiveStale:     "🗄 Archive stale",
+		BtnStaleUrgent:      "🔴 Make urgent",
+		BtnStaleLater:       "⚪ Lower priority",
+		ReviewArchived:      "🗄 Archived %d stale tasks.",
+		ReviewReprioritized: "Updated priority for %d stale tasks.",
 	},
 	"ru": {
 		Welcome:       "👋 <b>Добро пожаловать в Focus!</b>\n\nМинималистичный менеджер задач с ИИ.",
@@ -35,8 +137,59 @@ var I18n = map[string]Texts{
 		BtnOpen:       "🚀 Открыть приложение",
 		BtnTry:        "🎯 Попробовать",
 		BreathingInfo: "🧘 <b>Дыхательное упражнение</b>\n\n1-минутная техника для улучшения концентрации:\n\n• Вдох (4 сек)\n• Задержка (4 сек)\n• Выдох (4 сек)\n• 5 циклов\n\nНажми на заголовок «Focus» в приложении, чтобы начать.",
-		Help:          "📖 <b>Руководство по Focus</b>\n\n<b>Как это работает:</b>\n1. Нажми кнопку «Запустить Focus»\n2. Записывай задачи голосом или текстом\n3. ИИ распределит их по категориям\n4. Свайпай между вкладками: Задачи / Долгие / Рутина\n\n<b>Дыхательное упражнение:</b>\nНажми на заголовок «Focus» в приложении\n\n<b>Горячие жесты:</b>\n• Свайп влево/вправо — смена вкладки\n• Нажми на задачу — меню действий",
+		Help:          "📖 <b>Руководство по Focus</b>\n\n<b>Как это работает:</b>\n1. Нажми кнопку «Запустить Focus»\n2. Записывай задачи голосом или текстом\n3. ИИ распределит их по категориям\n4. Свайпай между вкладками: Задачи / Долгие / Рутина\n\n<b>Дыхательное упражнение:</b>\nНажми на заголовок «Focus» в приложении\n\n<b>Горячие жесты:</b>\n• Свайп влево/вправо — смена вкладки\n• Нажми на задачу — меню действий\n\n<b>Команды:</b>\n/today — на сегодня и срочное\n/list [task|long|routine] — открытые задачи\n/done — отметить выполненное\n/stats — твоя неделя в цифрах",
 		About:         "ℹ️ <b>О приложении Focus</b>\n\n<b>Версия:</b> 0.0.4\n\n<b>Технологии:</b>\n• PostgreSQL для хранения данных\n• Google Gemini AI для обработки задач\n• Go бэкенд для API\n\n<b>Приватность:</b>\n• Данные хранятся безопасно на наших серверах\n• Никаких сторонних аккаунтов\n• Защищённый API для всех запросов",
+
+		ReminderTitle:    "⏰ <b>Напоминание</b>",
+		ReminderDone:     "✅ <b>Готово</b>",
+		ReminderSnoozed:  "💤 <b>Отложено на 1 час</b>",
+		ReminderTomorrow: "📅 <b>Перенесено на завтра</b>",
+		ReminderGone:     "Эта задача больше не активна.",
+		BtnDone:          "✅ Готово",
+		BtnSnooze:        "💤 1 ч",
+		BtnTomorrow:      "📅 Завтра",
+
+		TasksAdded:    "✅ <b>Добавлено задач: %d</b>",
+		NoTasksFound:  "🤔 Не нашёл здесь задач. Попробуешь ещё раз?",
+		VoiceFailed:   "⚠️ Не удалось обработать голосовое. Попробуй ещё раз.",
+		VoiceTooLarge: "⚠️ Голосовое слишком большое (макс. 5 МБ).",
+		BtnUndo:       "↩️ Отменить: %s",
+
+		TextTooLong:    "⚠️ Слишком длинно (макс. 2000 символов).",
+		BtnTypeTask:    "Задача",
+		BtnTypeLong:    "Долгая",
+		BtnTypeRoutine: "Рутина",
+
+		TodayTitle:   "☀️ <b>Сегодня</b>",
+		ListTitle:    "📋 <b>%s</b>",
+		DoneTitle:    "✅ <b>Нажми на задачу, чтобы завершить её</b>",
+		NothingToday: "На сегодня ничего нет. Наслаждайся спокойствием 🌿",
+		ListEmpty:    "Открытых задач нет 🎉",
+
+		CmdStart: "Запустить Focus",
+		CmdToday: "На сегодня и срочное",
+		CmdList:  "Открытые задачи: task, long или routine",
+		CmdDone:  "Завершить задачу",
+		CmdHelp:  "Как пользоваться Focus",
+		CmdAbout: "О приложении",
+
+		CmdStats: "Твоя неделя в цифрах",
+		Summary:  "📊 <b>Последние 7 дней</b>\n\n<b>Задачи:</b>\n• Выполнено: %d\n• Серия: %d дн.\n\n<b>Дыхание:</b>\n• Сессий: %d\n• Осознанных минут: %d\n• Серия: %d дн.",
+
+		DigestTitle:    "🌅 <b>Доброе утро!</b> Вот твой день:",
+		DigestPriority: "🔴 <b>Приоритет</b>",
+		DigestOverdue:  "⏳ <b>Просрочено</b>",
+		DigestRoutines: "🔁 <b>Рутины</b>",
+
+		ReviewTitle:         "🗓 <b>Итоги недели</b>",
+		ReviewDone:          "✅ <b>Сделано за неделю: %d</b>",
+		ReviewStale:         "🕸 <b>Без движения %d+ дн.: %d</b>",
+		ReviewMore:          "…и ещё %d",
+		BtnArchiveStale:     "🗄 В архив",
+		BtnStaleUrgent:      "🔴 Сделать срочными",
+		BtnStaleLater:       "⚪ Понизить приоритет",
+		ReviewArchived:      "🗄 В архив отправлено задач: %d.",
+		ReviewReprioritized: "Приоритет обновлён у задач: %d.",
 	},
 }
 
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Kept below the client's 30s HTTP timeout.
+	pollTimeout    = 25
+	pollRetryDelay = 3 * time.Second
+	offsetKey      = "polling_offset"
+)
+
+// Poller feeds getUpdates results into Process, for deployments that
+// can't receive webhooks. The last handled update_id is stored in bot_state
+// so a restart resumes after it instead of replaying.
+type Poller struct {
+	done chan struct{}
+}
+
+func NewPoller() *Poller {
+	return &Poller{done: make(chan struct{})}
+}
+
+func (p *Poller) Start(ctx context.Context) {
+	go func() {
+		defer close(p.done)
+		p.run(ctx)
+	}()
+}
+
+func (p *Poller) Wait() {
+	<-p.done
+}
+
+func (p *Poller) run(ctx context.Context) {
+	c := api()
+
+	// getUpdates is refused while a webhook is registered.
+	if err := c.DeleteWebhook(ctx); err != nil {
+		log.Printf("Poller: deleteWebhook error: %v", err)
+	}
+
+	offset, err := loadOffset(ctx)
+	if err != nil {
+		log.Printf("Poller: load offset error: %v", err)
+	}
+	log.Printf("Poller: started (offset %d)", offset)
+
+	for ctx.Err() == nil {
+		updates, err := c.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Poller: getUpdates error: %v", err)
+			sleepCtx(ctx, pollRetryDelay)
+			continue
+		}
+
+		// An update that has started is finished even during shutdown, so
+		// its offset is saved and it isn't replayed on restart.
+		hctx := context.WithoutCancel(ctx)
+		for i := range updates {
+			if ctx.Err() != nil {
+				break
+			}
+			update := updates[i]
+			if err := Process(hctx, &update); err != nil {
+				log.Printf("Poller: update %d error: %v", update.UpdateID, err)
+			}
+			offset = update.UpdateID + 1
+			if err := saveOffset(hctx, offset); err != nil {
+				log.Printf("Poller: save offset error: %v", err)
+			}
+		}
+	}
+
+	log.Println("Poller: stopped")
+}
+
+func loadOffset(ctx context.Context) (int64, error) {
+	var offset int64
+	err := db.Pool.QueryRow(ctx, `SELECT value FROM bot_state WHERE key = $1`, offsetKey).Scan(&offset)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return offset, err
+}
+
+func saveOffset(ctx context.Context, offset int64) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO bot_state (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = $2, updated_at = NOW()
+	`, offsetKey, offset)
+	return err
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	cbReminderDone     = "rem_done:"
+	cbReminderSnooze   = "rem_snooze:"
+	cbReminderTomorrow = "rem_tomorrow:"
+)
+
+type Reminder struct {
+	TaskID   int64
+	UserID   int64
+	Title    string
+	Language string
+}
+
+// SendReminder delivers a due-task reminder to the user's private chat.
+func SendReminder(ctx context.Context, r Reminder) error {
+	t := GetTexts(r.Language)
+	id := strconv.FormatInt(r.TaskID, 10)
+
+	text := fmt.Sprintf("%s\n\n%s", t.ReminderTitle, html.EscapeString(r.Title))
+	keyboard := InlineKeyboard{
+		InlineKeyboard: [][]InlineButton{
+			{
+				{Text: t.BtnDone, CallbackData: cbReminderDone + id},
+				{Text: t.BtnSnooze, CallbackData: cbReminderSnooze + id},
+				{Text: t.BtnTomorrow, CallbackData: cbReminderTomorrow + id},
+			},
+		},
+	}
+
+	return sendMessage(ctx, r.UserID, text, keyboard)
+}
+
+// unblockReminders resumes reminders stopped by a 403; a user who sends
+// /start has unblocked the bot.
+func unblockReminders(ctx context.Context, userID int64) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE users SET reminders_blocked = FALSE WHERE id = $1 AND reminders_blocked
+	`, userID)
+	return err
+}
+
+func isReminderCallback(data string) bool {
+	return strings.HasPrefix(data, cbReminderDone) ||
+		strings.HasPrefix(data, cbReminderSnooze) ||
+		strings.HasPrefix(data, cbReminderTomorrow)
+}
+
+func handleReminderCallback(ctx context.Context, cb *CallbackQuery, t Texts) error {
+	if cb.From == nil || cb.Message == nil {
+		return nil
+	}
+
+	action, rawID, _ := strings.Cut(cb.Data, ":")
+	taskID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	var query, status string
+	args := []interface{}{taskID, cb.From.ID}
+	switch action + ":" {
+	case cbReminderDone:
+		status = t.ReminderDone
+	case cbReminderSnooze:
+		query = `
+			UPDATE tasks SET due_at = NOW() + INTERVAL '1 hour', reminder_sent = FALSE, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND NOT completed
+			RETURNING title`
+		status = t.ReminderSnoozed
+	case cbReminderTomorrow:
+		status = t.ReminderTomorrow
+	default:
+		return nil
+	}
+
+	var title string
+	switch action + ":" {
+	case cbReminderDone:
+		title, err = completeTask(ctx, cb.From.ID, taskID)
+	case cbReminderTomorrow:
+		title, err = postponeToTomorrow(ctx, cb.From.ID, taskID)
+	default:
+		err = db.Pool.QueryRow(ctx, query, args...).Scan(&title)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Task deleted or already completed — drop the stale buttons.
+		status = t.ReminderGone
+	} else if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("%s\n\n%s", status, html.EscapeString(title))
+	return api().EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, strings.TrimSpace(text), InlineKeyboard{})
+}
+
+// postponeToTomorrow moves a task to tomorrow in the user's timezone, keeping
+// the local time of day it was due at.
+func postponeToTomorrow(ctx context.Context, userID, taskID int64) (string, error) {
+	loc := db.UserLocation(ctx, db.Pool, userID)
+	var title string
+	err := pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+		var due *time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT title, due_at FROM tasks
+			WHERE id = $1 AND user_id = $2 AND NOT completed
+			FOR UPDATE
+		`, taskID, userID).Scan(&title, &due)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE tasks SET due_at = $2, reminder_sent = FALSE, updated_at = NOW() WHERE id = $1
+		`, taskID, tomorrowAt(due, time.Now(), loc))
+		return err
+	})
+	return title, err
+}
+
+// tomorrowAt returns the day after now's local date in loc, at due's local
+// time of day (or now's when there is no due date). Building the time from
+// the wall clock keeps the hour across DST changes.
+func tomorrowAt(due *time.Time, now time.Time, loc *time.Location) time.Time {
+	clock := now.In(loc)
+	if due != nil {
+		clock = due.In(loc)
+	}
+	day := now.In(loc).AddDate(0, 0, 1)
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+}
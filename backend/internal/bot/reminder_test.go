@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTomorrowAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	at := func(loc *time.Location, d, h, m int) time.Time { return time.Date(2026, 3, d, h, m, 0, 0, loc) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name string
+		due  *time.Time
+		now  time.Time
+		want time.Time
+	}{
+		{"due today", ptr(at(berlin, 10, 18, 0)), at(berlin, 10, 18, 5), at(berlin, 11, 18, 0)},
+		{"overdue keeps the time of day", ptr(at(berlin, 7, 9, 30)), at(berlin, 10, 21, 0), at(berlin, 11, 9, 30)},
+		{"local date, not UTC", ptr(at(berlin, 10, 8, 0)), at(berlin, 11, 0, 30), at(berlin, 12, 8, 0)},
+		{"across the DST change", ptr(at(berlin, 28, 9, 0)), at(berlin, 28, 9, 1), at(berlin, 29, 9, 0)},
+		{"no due date", nil, at(berlin, 10, 14, 15), at(berlin, 11, 14, 15)},
+	}
+	for _, tt := range tests {
+		got := tomorrowAt(tt.due, tt.now.UTC(), berlin)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: tomorrowAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+)
+
+const (
+	// StaleAfterDays is how old an open task must be to count as stale.
+	StaleAfterDays   = 14
+	reviewListLimit  = 10
+	cbReviewArchive  = "rev_archive:"
+	cbReviewUrgent   = "rev_urgent:"
+	cbReviewLower    = "rev_lower:"
+	staleTasksFilter = `user_id = $1 AND NOT completed AND archived_at IS NULL
+		AND recurrence IS NULL AND parent_id IS NULL AND created_at < $2`
+	// reviewDoneQuery selects the completions since $2: one-off tasks plus
+	// every occurrence of a routine, which stays open in tasks.
+	reviewDoneQuery = `
+		SELECT title, completed_at FROM tasks
+		WHERE user_id = $1 AND completed AND parent_id IS NULL AND completed_at >= $2
+		UNION ALL
+		SELECT t.title, c.completed_at FROM task_completions c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE c.user_id = $1 AND c.completed_at >= $2`
+)
+
+type Review struct {
+	UserID   int64
+	Language string
+}
+
+// SendReview sends the weekly review: tasks completed in the last 7 days and
+// open tasks older than StaleAfterDays, with bulk actions for the stale ones.
+// Nothing is sent when both lists are empty.
+func SendReview(ctx context.Context, r Review) error {
+	t := GetTexts(r.Language)
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -StaleAfterDays).Truncate(time.Second)
+
+	var doneCount int
+	weekAgo := now.AddDate(0, 0, -7)
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM (`+reviewDoneQuery+`) done`, r.UserID, weekAgo).Scan(&doneCount)
+	if err != nil {
+		return err
+	}
+	var staleCount int
+	err = db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM tasks WHERE `+staleTasksFilter, r.UserID, cutoff).Scan(&staleCount)
+	if err != nil {
+		return err
+	}
+	if doneCount == 0 && staleCount == 0 {
+		return nil
+	}
+
+	parts := []string{t.ReviewTitle}
+
+	if doneCount > 0 {
+		done, err := digestTitles(ctx, `
+			SELECT title FROM (`+reviewDoneQuery+`) done
+			ORDER BY completed_at DESC
+			LIMIT $3
+		`, r.UserID, weekAgo, reviewListLimit)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, reviewSection(fmt.Sprintf(t.ReviewDone, doneCount), done, doneCount, t))
+	}
+
+	var keyboard InlineKeyboard
+	if staleCount > 0 {
+		stale, err := digestTitles(ctx, `
+			SELECT title FROM tasks WHERE `+staleTasksFilter+`
+			ORDER BY created_at
+			LIMIT $3
+		`, r.UserID, cutoff, reviewListLimit)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, reviewSection(fmt.Sprintf(t.ReviewStale, StaleAfterDays, staleCount), stale, staleCount, t))
+
+		// The cutoff identifies the stale set, so the buttons act on exactly
+		// the tasks that were stale when the review was sent.
+		ts := strconv.FormatInt(cutoff.Unix(), 10)
+		keyboard.InlineKeyboard = [][]InlineButton{
+			{{Text: t.BtnArchiveStale, CallbackData: cbReviewArchive + ts}},
+			{
+				{Text: t.BtnStaleUrgent, CallbackData: cbReviewUrgent + ts},
+				{Text: t.BtnStaleLater, CallbackData: cbReviewLower + ts},
+			},
+		}
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []InlineButton{{Text: t.BtnOpen, WebApp: &WebApp{URL: WebAppURL}}})
+
+	return sendMessage(ctx, r.UserID, strings.Join(parts, "\n\n"), keyboard)
+}
+
+func reviewSection(header string, titles []string, total int, t Texts) string {
+	lines := []string{header}
+	for _, title := range titles {
+		lines = append(lines, "• "+html.EscapeString(title))
+	}
+	if total > len(titles) {
+		lines = append(lines, fmt.Sprintf(t.ReviewMore, total-len(titles)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isReviewCallback(data string) bool {
+	return strings.HasPrefix(data, cbReviewArchive) ||
+		strings.HasPrefix(data, cbReviewUrgent) ||
+		strings.HasPrefix(data, cbReviewLower)
+}
+
+// handleReviewCallback applies a bulk action to the stale tasks listed in a
+// weekly review, then drops the review's action buttons.
+func handleReviewCallback(ctx context.Context, cb *CallbackQuery, t Texts) error {
+	if cb.From == nil || cb.Message == nil {
+		return nil
+	}
+
+	action, rawCutoff, _ := strings.Cut(cb.Data, ":")
+	unix, err := strconv.ParseInt(rawCutoff, 10, 64)
+	if err != nil {
+		return nil
+	}
+	cutoff := time.Unix(unix, 0)
+
+	var query, status string
+	switch action + ":" {
+	case cbReviewArchive:
+		query = `UPDATE tasks SET archived_at = NOW(), updated_at = NOW() WHERE ` + staleTasksFilter
+		status = t.ReviewArchived
+	case cbReviewUrgent:
+		query = `UPDATE tasks SET priority = 1, updated_at = NOW() WHERE ` + staleTasksFilter
+		status = t.ReviewReprioritized
+	case cbReviewLower:
+		query = `UPDATE tasks SET priority = 3, updated_at = NOW() WHERE ` + staleTasksFilter
+		status = t.ReviewReprioritized
+	default:
+		return nil
+	}
+
+	tag, err := db.Pool.Exec(ctx, query, cb.From.ID, cutoff)
+	if err != nil {
+		return fmt.Errorf("review action: %w", err)
+	}
+	if action+":" == cbReviewArchive && tag.RowsAffected() > 0 {
+		events.Record(ctx, cb.From.ID, events.TasksArchived, map[string]interface{}{
+			"count": tag.RowsAffected(), "source": events.SourceBot,
+		})
+	}
+
+	keyboard := InlineKeyboard{
+		InlineKeyboard: [][]InlineButton{
+			{{Text: t.BtnOpen, WebApp: &WebApp{URL: WebAppURL}}},
+		},
+	}
+	if err := api().EditMessageReplyMarkup(ctx, cb.Message.Chat.ID, cb.Message.MessageID, keyboard); err != nil {
+		return err
+	}
+	return sendMessage(ctx, cb.Message.Chat.ID, fmt.Sprintf(status, tag.RowsAffected()), InlineKeyboard{})
+}
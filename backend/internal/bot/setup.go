@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Telegram only accepts these characters in a webhook secret_token.
+var secretTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Commands returns the command menu in the language of t.
+func Commands(t Texts) []BotCommand {
+	return []BotCommand{
+		{Command: "start", Description: t.CmdStart},
+		{Command: "today", Description: t.CmdToday},
+		{Command: "list", Description: t.CmdList},
+		{Command: "done", Description: t.CmdDone},
+		{Command: "stats", Description: t.CmdStats},
+		{Command: "help", Description: t.CmdHelp},
+		{Command: "about", Description: t.CmdAbout},
+	}
+}
+
+// Setup registers the webhook (when webhookURL is set) with Telegram's
+// secret_token header, and the localized command menus from I18n.
+func Setup(ctx context.Context, webhookURL, secret string) error {
+	c := api()
+
+	if webhookURL != "" {
+		if secret != "" && !secretTokenPattern.MatchString(secret) {
+			return errors.New("WEBHOOK_SECRET may only contain A-Z, a-z, 0-9, _ and - (max 256)")
+		}
+		err := c.SetWebhook(ctx, WebhookParams{
+			URL:            webhookURL,
+			SecretToken:    secret,
+			AllowedUpdates: []string{"message", "callback_query"},
+		})
+		if err != nil {
+			return fmt.Errorf("set webhook: %w", err)
+		}
+	}
+
+	if err := c.SetMyCommands(ctx, Commands(I18n["en"]), ""); err != nil {
+		return fmt.Errorf("set default commands: %w", err)
+	}
+	for lang, t := range I18n {
+		if err := c.SetMyCommands(ctx, Commands(t), lang); err != nil {
+			return fmt.Errorf("set %s commands: %w", lang, err)
+		}
+	}
+
+	return nil
+}
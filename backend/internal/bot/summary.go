@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/stats"
+)
+
+const summaryDays = 7
+
+// sendSummary replies with the user's last-week tasks and breathing streaks.
+func sendSummary(ctx context.Context, chatID, userID int64, t Texts) error {
+	loc, today := db.UserToday(ctx, db.Pool, userID)
+
+	s, err := stats.Build(ctx, db.Pool, userID, loc, today.AddDate(0, 0, -(summaryDays-1)), today, today)
+	if err != nil {
+		return err
+	}
+
+	completed := 0
+	for _, d := range s.PerDay {
+		completed += d.Count
+	}
+
+	text := fmt.Sprintf(t.Summary,
+		completed, s.CurrentStreak,
+		s.Breathing.CompletedSessions, int(s.Breathing.Minutes+0.5), s.Breathing.CurrentStreak)
+
+	keyboard := InlineKeyboard{
+		InlineKeyboard: [][]InlineButton{
+			{{Text: t.BtnOpen, WebApp: &WebApp{URL: WebAppURL}}},
+		},
+	}
+	return sendMessage(ctx, chatID, text, keyboard)
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/services"
+)
+
+const (
+	maxTitleLength = 200
+	maxTextLength  = 2000 // same as /tasks/parse
+	cbSetType      = "settype:"
+)
+
+var taskTypes = []string{"Task", "Long", "Routine"}
+
+// handleText stores free text as tasks. Short single-line input becomes one
+// task verbatim; anything that looks like a list goes through Gemini.
+func handleText(ctx context.Context, msg *Message, t Texts) error {
+	if msg.From == nil {
+		return nil
+	}
+	chatID := msg.Chat.ID
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(text) > maxTextLength {
+		return sendMessage(ctx, chatID, t.TextTooLong, InlineKeyboard{})
+	}
+
+	if err := ensureUser(ctx, msg.From); err != nil {
+		return err
+	}
+
+	if looksLikeList(text) {
+		loc := db.UserLocation(ctx, db.Pool, msg.From.ID)
+		parsed, err := services.ParseTextTasks(text, "Task", db.Language(msg.From.LanguageCode), loc)
+		if err == nil {
+			return replyCreated(ctx, chatID, msg.From.ID, parsed, text, events.SourceText, t)
+		}
+		log.Printf("Bot ParseTextTasks error, storing as single task: %v", err)
+	}
+
+	title := text
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength])
+	}
+	single := []services.Task{{Title: title, Type: "Task", Priority: 2}}
+	if err := services.ValidateTask(single[0]); err != nil {
+		log.Printf("Bot text task rejected: %v", err)
+		return sendMessage(ctx, chatID, t.NoTasksFound, InlineKeyboard{})
+	}
+	return replyCreated(ctx, chatID, msg.From.ID, single, text, events.SourceText, t)
+}
+
+func looksLikeList(text string) bool {
+	if strings.Contains(text, "\n") {
+		return true
+	}
+	if strings.Count(text, ",")+strings.Count(text, ";") >= 2 {
+		return true
+	}
+	return len(strings.Fields(text)) > 10
+}
+
+// typeRow renders the Task / Long / Routine switch with the active one ticked.
+func typeRow(active string, t Texts) []InlineButton {
+	labels := map[string]string{
+		"Task":    t.BtnTypeTask,
+		"Long":    t.BtnTypeLong,
+		"Routine": t.BtnTypeRoutine,
+	}
+	row := make([]InlineButton, 0, len(taskTypes))
+	for _, tt := range taskTypes {
+		label := labels[tt]
+		if tt == active {
+			label = "✓ " + label
+		}
+		row = append(row, InlineButton{Text: label, CallbackData: cbSetType + tt})
+	}
+	return row
+}
+
+// handleSetTypeCallback retypes every task still listed in the confirmation
+// message. Task IDs are recovered from the message's undo buttons.
+func handleSetTypeCallback(ctx context.Context, cb *CallbackQuery, t Texts) error {
+	if cb.From == nil || cb.Message == nil || cb.Message.ReplyMarkup == nil {
+		return nil
+	}
+
+	taskType := strings.TrimPrefix(cb.Data, cbSetType)
+	valid := false
+	for _, tt := range taskTypes {
+		if tt == taskType {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil
+	}
+
+	var ids []int64
+	var rows [][]InlineButton
+	for _, row := range cb.Message.ReplyMarkup.InlineKeyboard {
+		if len(row) == 0 || !strings.HasPrefix(row[0].CallbackData, cbUndo) {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(row[0].CallbackData, cbUndo), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		rows = append(rows, row)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE tasks SET task_type = $1, updated_at = NOW()
+		WHERE id = ANY($2) AND user_id = $3
+	`, taskType, ids, cb.From.ID)
+	if err != nil {
+		return fmt.Errorf("set task type: %w", err)
+	}
+
+	rows = append(rows, typeRow(taskType, t))
+	return api().EditMessageReplyMarkup(ctx, cb.Message.Chat.ID, cb.Message.MessageID, InlineKeyboard{InlineKeyboard: rows})
+}
@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/services"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxVoiceSize = 5 * 1024 * 1024 // 5MB, same as /tasks/audio
+	cbUndo       = "undo:"
+)
+
+type Voice struct {
+	FileID   string `json:"file_id"`
+	Duration int    `json:"duration"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
+type File struct {
+	FileID   string `json:"file_id"`
+	FileSize int64  `json:"file_size,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+}
+
+// handleVoice transcribes a voice note into tasks for the sender and
+// replies with a summary plus one undo button per created task.
+func handleVoice(ctx context.Context, msg *Message, t Texts) error {
+	if msg.From == nil {
+		return nil
+	}
+	chatID := msg.Chat.ID
+
+	if msg.Voice.FileSize > maxVoiceSize {
+		return sendMessage(ctx, chatID, t.VoiceTooLarge, InlineKeyboard{})
+	}
+
+	audio, err := downloadFile(ctx, msg.Voice.FileID)
+	if err != nil {
+		log.Printf("Voice download error: %v", err)
+		return sendMessage(ctx, chatID, t.VoiceFailed, InlineKeyboard{})
+	}
+
+	mimeType := msg.Voice.MimeType
+	if mimeType == "" {
+		mimeType = "audio/ogg"
+	}
+
+	if err := ensureUser(ctx, msg.From); err != nil {
+		return err
+	}
+
+	loc := db.UserLocation(ctx, db.Pool, msg.From.ID)
+	parsed, err := services.TranscribeAndParseTasks(audio, mimeType, "Task", db.Language(msg.From.LanguageCode), loc, false)
+	if err != nil {
+		log.Printf("Bot TranscribeAndParseTasks error: %v", err)
+		return sendMessage(ctx, chatID, t.VoiceFailed, InlineKeyboard{})
+	}
+
+	return replyCreated(ctx, chatID, msg.From.ID, parsed, "[voice]", events.SourceVoice, t)
+}
+
+// replyCreated stores parsed tasks for userID and confirms them in chat.
+// input is the capture method (text or voice) recorded with the event.
+func replyCreated(ctx context.Context, chatID, userID int64, parsed []services.Task, original, input string, t Texts) error {
+	loc := db.UserLocation(ctx, db.Pool, userID)
+
+	var lines []string
+	var rows [][]InlineButton
+	active := ""
+	for i, pt := range parsed {
+		if i == 0 {
+			active = pt.Type
+		} else if pt.Type != active {
+			active = ""
+		}
+
+		var id int64
+		err := db.Pool.QueryRow(ctx, `
+			INSERT INTO tasks (user_id, title, original_input, task_type, priority, due_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, userID, pt.Title, original, pt.Type, pt.Priority, pt.DueAt).Scan(&id)
+		if err != nil {
+			log.Printf("Bot task insert error: %v", err)
+			continue
+		}
+
+		events.Record(ctx, userID, events.TaskCreated, map[string]interface{}{
+			"task_id": id, "title": pt.Title, "type": pt.Type, "source": events.SourceBot, "input": input,
+		})
+
+		line := "• " + html.EscapeString(pt.Title)
+		if pt.DueAt != nil {
+			line += " — " + pt.DueAt.In(loc).Format("Mon 02 Jan 15:04")
+		}
+		lines = append(lines, line)
+		rows = append(rows, []InlineButton{{
+			Text:         fmt.Sprintf(t.BtnUndo, pt.Title),
+			CallbackData: cbUndo + strconv.FormatInt(id, 10),
+		}})
+	}
+
+	if len(lines) == 0 {
+		return sendMessage(ctx, chatID, t.NoTasksFound, InlineKeyboard{})
+	}
+
+	rows = append(rows, typeRow(active, t))
+
+	text := fmt.Sprintf(t.TasksAdded, len(lines)) + "\n\n" + strings.Join(lines, "\n")
+	return sendMessage(ctx, chatID, text, InlineKeyboard{InlineKeyboard: rows})
+}
+
+// handleUndoCallback deletes the task and drops its button from the summary.
+func handleUndoCallback(ctx context.Context, cb *CallbackQuery) error {
+	if cb.From == nil || cb.Message == nil {
+		return nil
+	}
+
+	taskID, err := strconv.ParseInt(strings.TrimPrefix(cb.Data, cbUndo), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	var title string
+	err = db.Pool.QueryRow(ctx, `
+		DELETE FROM tasks WHERE id = $1 AND user_id = $2
+		RETURNING title
+	`, taskID, cb.From.ID).Scan(&title)
+	if err == nil {
+		events.Record(ctx, cb.From.ID, events.TaskDeleted, map[string]interface{}{
+			"task_id": taskID, "title": title, "source": events.SourceBot,
+		})
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	keyboard := InlineKeyboard{InlineKeyboard: [][]InlineButton{}}
+	remaining := 0
+	if cb.Message.ReplyMarkup != nil {
+		for _, row := range cb.Message.ReplyMarkup.InlineKeyboard {
+			if len(row) > 0 && row[0].CallbackData == cb.Data {
+				continue
+			}
+			if len(row) > 0 && strings.HasPrefix(row[0].CallbackData, cbUndo) {
+				remaining++
+			}
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+		}
+	}
+	if remaining == 0 {
+		// Nothing left to undo or retype.
+		keyboard.InlineKeyboard = [][]InlineButton{}
+	}
+
+	return api().EditMessageReplyMarkup(ctx, cb.Message.Chat.ID, cb.Message.MessageID, keyboard)
+}
+
+func ensureUser(ctx context.Context, u *User) error {
+	return db.EnsureUser(ctx, db.Pool, db.Profile{
+		ID:           u.ID,
+		FirstName:    u.FirstName,
+		Username:     u.Username,
+		LanguageCode: u.LanguageCode,
+	})
+}
+
+func downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	file, err := api().GetFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.FilePath == "" {
+		return nil, errors.New("getFile returned no file_path")
+	}
+	if file.FileSize > maxVoiceSize {
+		return nil, errors.New("file too large")
+	}
+	return api().DownloadFile(ctx, file.FilePath, maxVoiceSize)
+}
@@ -0,0 +1,4 @@
+-- 001_init.down.sql
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,2 @@
+-- 002_bot_state.down.sql
+DROP TABLE IF EXISTS bot_state;
@@ -0,0 +1,6 @@
+-- 002_bot_state.sql
+CREATE TABLE IF NOT EXISTS bot_state (
+    key TEXT PRIMARY KEY,
+    value BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
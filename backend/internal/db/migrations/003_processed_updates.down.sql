@@ -0,0 +1,2 @@
+-- 003_processed_updates.down.sql
+DROP TABLE IF EXISTS processed_updates;
@@ -0,0 +1,7 @@
+-- 003_processed_updates.sql
+CREATE TABLE IF NOT EXISTS processed_updates (
+    update_id BIGINT PRIMARY KEY,
+    processed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_updates_date ON processed_updates(processed_at);
@@ -0,0 +1,2 @@
+-- 004_breathing_sessions.down.sql
+DROP TABLE IF EXISTS breathing_sessions;
@@ -0,0 +1,12 @@
+-- 004_breathing_sessions.sql
+CREATE TABLE IF NOT EXISTS breathing_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ NOT NULL,
+    cycles INTEGER NOT NULL DEFAULT 0,
+    aborted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_breathing_user_date ON breathing_sessions(user_id, started_at);
@@ -0,0 +1,5 @@
+-- 005_routines.down.sql
+DROP TABLE IF EXISTS task_completions;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS next_occurrence;
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence;
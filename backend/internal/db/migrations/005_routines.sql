@@ -0,0 +1,14 @@
+-- 005_routines.sql
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS next_occurrence DATE;
+
+CREATE TABLE IF NOT EXISTS task_completions (
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    occurrence DATE NOT NULL,
+    completed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_completions_user_date ON task_completions(user_id, completed_at);
+CREATE INDEX IF NOT EXISTS idx_task_completions_task ON task_completions(task_id, completed_at);
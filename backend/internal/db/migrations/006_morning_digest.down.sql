@@ -0,0 +1,4 @@
+-- 006_morning_digest.down.sql
+ALTER TABLE users DROP COLUMN IF EXISTS digest_sent_on;
+ALTER TABLE users DROP COLUMN IF EXISTS digest_hour;
+ALTER TABLE users DROP COLUMN IF EXISTS digest_enabled;
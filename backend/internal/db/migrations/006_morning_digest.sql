@@ -0,0 +1,4 @@
+-- 006_morning_digest.sql
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_enabled BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_hour INTEGER DEFAULT 8;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_sent_on DATE;
@@ -0,0 +1,5 @@
+-- 007_weekly_review.down.sql
+ALTER TABLE users DROP COLUMN IF EXISTS review_sent_on;
+ALTER TABLE users DROP COLUMN IF EXISTS review_enabled;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS archived_at;
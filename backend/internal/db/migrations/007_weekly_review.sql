@@ -0,0 +1,8 @@
+-- 007_weekly_review.sql
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+-- Unlike the daily digest (006), the review is opt-out: it is one message a
+-- week and is only sent when there is something to report, so it is on by
+-- default and a 403 or the preferences toggle turns it off.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_enabled BOOLEAN DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_sent_on DATE;
@@ -0,0 +1,5 @@
+-- 008_subtasks.down.sql
+DROP INDEX IF EXISTS idx_tasks_parent;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS position;
+ALTER TABLE tasks DROP COLUMN IF EXISTS parent_id;
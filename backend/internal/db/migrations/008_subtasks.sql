@@ -0,0 +1,5 @@
+-- 008_subtasks.sql
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES tasks(id) ON DELETE CASCADE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position DOUBLE PRECISION;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks(parent_id, position) WHERE parent_id IS NOT NULL;
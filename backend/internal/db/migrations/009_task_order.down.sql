@@ -0,0 +1,4 @@
+-- 009_task_order.down.sql
+DROP INDEX IF EXISTS idx_tasks_user_position;
+
+ALTER TABLE users DROP COLUMN IF EXISTS task_order;
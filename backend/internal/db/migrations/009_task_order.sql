@@ -0,0 +1,4 @@
+-- 009_task_order.sql
+ALTER TABLE users ADD COLUMN IF NOT EXISTS task_order TEXT DEFAULT 'priority';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_position ON tasks(user_id, task_type, position) WHERE parent_id IS NULL;
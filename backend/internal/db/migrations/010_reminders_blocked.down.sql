@@ -0,0 +1,2 @@
+-- 010_reminders_blocked.down.sql
+ALTER TABLE users DROP COLUMN IF EXISTS reminders_blocked;
@@ -0,0 +1,2 @@
+-- 010_reminders_blocked.sql
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reminders_blocked BOOLEAN DEFAULT FALSE;
@@ -4,11 +4,21 @@ import (
 	"context"
 	"os"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var Pool *pgxpool.Pool
 
+// Querier is the part of *pgxpool.Pool and pgx.Tx the helpers here use, so
+// callers can run them on their own pool or inside a transaction.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 func Connect() error {
 	var err error
 	Pool, err = pgxpool.New(context.Background(), os.Getenv("DATABASE_URL"))
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/timezone"
+)
+
+// UserLocation returns the user's stored timezone, falling back to UTC.
+func UserLocation(ctx context.Context, q Querier, userID int64) *time.Location {
+	var name string
+	err := q.QueryRow(ctx, `SELECT timezone FROM users WHERE id = $1`, userID).Scan(&name)
+	if err != nil {
+		return time.UTC
+	}
+	return Location(name)
+}
+
+// UserToday returns the user's timezone and local midnight of their today.
+func UserToday(ctx context.Context, q Querier, userID int64) (*time.Location, time.Time) {
+	loc := UserLocation(ctx, q, userID)
+	return loc, timezone.Today(loc)
+}
+
+// Location loads a stored timezone name, falling back to UTC.
+func Location(name string) *time.Location {
+	loc, err := timezone.Load(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Profile is the Telegram identity a users row is created from.
+type Profile struct {
+	ID           int64
+	FirstName    string
+	Username     string
+	LanguageCode string
+}
+
+const (
+	profileCacheTTL  = 10 * time.Minute
+	profileCacheSize = 10000
+)
+
+var profileCache = struct {
+	sync.Mutex
+	entries map[int64]profileEntry
+}{entries: map[int64]profileEntry{}}
+
+type profileEntry struct {
+	profile Profile
+	expires time.Time
+}
+
+// EnsureUser upserts the users row for p so task inserts satisfy the foreign
+// key. Name and username follow Telegram; language is only set on insert,
+// since users may change it in preferences. Unchanged profiles seen within
+// the last few minutes skip the write.
+func EnsureUser(ctx context.Context, q Querier, p Profile) error {
+	now := time.Now()
+	profileCache.Lock()
+	e, ok := profileCache.entries[p.ID]
+	profileCache.Unlock()
+	if ok && e.profile == p && now.Before(e.expires) {
+		return nil
+	}
+
+	_, err := q.Exec(ctx, `
+		INSERT INTO users (id, first_name, username, language)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			first_name = EXCLUDED.first_name,
+			username = EXCLUDED.username,
+			updated_at = NOW()
+		WHERE users.first_name IS DISTINCT FROM EXCLUDED.first_name
+			OR users.username IS DISTINCT FROM EXCLUDED.username
+	`, p.ID, p.FirstName, p.Username, Language(p.LanguageCode))
+	if err != nil {
+		return err
+	}
+
+	profileCache.Lock()
+	if len(profileCache.entries) >= profileCacheSize {
+		profileCache.entries = map[int64]profileEntry{}
+	}
+	profileCache.entries[p.ID] = profileEntry{profile: p, expires: now.Add(profileCacheTTL)}
+	profileCache.Unlock()
+	return nil
+}
+
+// Language maps a Telegram language_code to a supported language.
+func Language(code string) string {
+	if strings.HasPrefix(code, "ru") {
+		return "ru"
+	}
+	return "en"
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+)
+
+// Event types written to the events table.
+const (
+	TaskCreated        = "task_created"
+	TaskCompleted      = "task_completed"
+	TaskDeleted        = "task_deleted"
+	TasksArchived      = "tasks_archived"
+	PreferencesChanged = "preferences_changed"
+	BreathingSession   = "breathing_session"
+	BotCommand         = "bot_command"
+)
+
+// Sources for TaskCreated.
+const (
+	SourceText  = "text"
+	SourceVoice = "voice"
+	SourceBot   = "bot"
+)
+
+var Types = []string{TaskCreated, TaskCompleted, TaskDeleted, TasksArchived, PreferencesChanged, BreathingSession, BotCommand}
+
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Recorder has Record's signature, so callers can swap in a no-op.
+type Recorder func(ctx context.Context, userID int64, eventType string, metadata map[string]interface{})
+
+// Record appends an event to the user's activity log. Failures are logged
+// and never break the action being recorded.
+func Record(ctx context.Context, userID int64, eventType string, metadata map[string]interface{}) {
+	Write(ctx, db.Pool, userID, eventType, metadata)
+}
+
+// Write is Record on q.
+func Write(ctx context.Context, q db.Querier, userID int64, eventType string, metadata map[string]interface{}) {
+	var raw []byte
+	if metadata != nil {
+		var err error
+		if raw, err = json.Marshal(metadata); err != nil {
+			log.Printf("events.Record marshal error: %v", err)
+			return
+		}
+	}
+
+	_, err := q.Exec(ctx, `
+		INSERT INTO events (user_id, event_type, metadata) VALUES ($1, $2, $3)
+	`, userID, eventType, raw)
+	if err != nil {
+		log.Printf("events.Record %s error: %v", eventType, err)
+	}
+}
+
+// List returns the user's events newest first. before is an exclusive event
+// ID cursor (0 for the first page); an empty types slice means all types.
+func List(ctx context.Context, q db.Querier, userID int64, types []string, before int64, limit int) ([]Event, error) {
+	if types == nil {
+		types = []string{}
+	}
+	rows, err := q.Query(ctx, `
+		SELECT id, event_type, metadata, created_at
+		FROM events
+		WHERE user_id = $1
+			AND ($2::bigint = 0 OR id < $2)
+			AND (cardinality($3::text[]) = 0 OR event_type = ANY($3))
+		ORDER BY id DESC
+		LIMIT $4
+	`, userID, before, types, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Event{}
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.Metadata, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type BreathingSession struct {
+	ID        int64     `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Cycles    int       `json:"cycles"`
+	Aborted   bool      `json:"aborted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateBreathingSessionRequest struct {
+	StartedAt time.Time `json:"started_at" binding:"required"`
+	EndedAt   time.Time `json:"ended_at" binding:"required"`
+	Cycles    int       `json:"cycles" binding:"min=0,max=100"`
+	Aborted   bool      `json:"aborted"`
+}
@@ -1,31 +1,90 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/recurrence"
+)
 
 type Task struct {
-	ID            int64      `json:"id"`
-	UserID        int64      `json:"user_id"`
-	Title         string     `json:"title"`
-	OriginalInput string     `json:"original,omitempty"`
-	TaskType      string     `json:"type"`
-	Priority      int        `json:"priority"`
-	Completed     bool       `json:"completed"`
-	CompletedAt   *time.Time `json:"completed_at,omitempty"`
-	DueAt         *time.Time `json:"due_at,omitempty"`
-	ReminderSent  bool       `json:"reminder_sent"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID             int64            `json:"id"`
+	UserID         int64            `json:"user_id"`
+	Title          string           `json:"title"`
+	OriginalInput  string           `json:"original,omitempty"`
+	TaskType       string           `json:"type"`
+	Priority       int              `json:"priority"`
+	Completed      bool             `json:"completed"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	DueAt          *time.Time       `json:"due_at,omitempty"`
+	ReminderSent   bool             `json:"reminder_sent"`
+	Recurrence     *recurrence.Rule `json:"recurrence,omitempty"`
+	NextOccurrence *string          `json:"next_occurrence,omitempty"`
+	ArchivedAt     *time.Time       `json:"archived_at,omitempty"`
+	ParentID       *int64           `json:"parent_id,omitempty"`
+	Position       *float64         `json:"position,omitempty"`
+	SubtasksTotal  int              `json:"subtasks_total,omitempty"`
+	SubtasksDone   int              `json:"subtasks_done,omitempty"`
+	Progress       *int             `json:"progress,omitempty"` // percent of subtasks done
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// SetProgress fills in the subtask counts; tasks without subtasks have no
+// progress.
+func (t *Task) SetProgress(total, done int) {
+	t.SubtasksTotal = total
+	t.SubtasksDone = done
+	t.Progress = nil
+	if total > 0 {
+		p := done * 100 / total
+		t.Progress = &p
+	}
 }
 
 type CreateTaskRequest struct {
-	Title    string `json:"title" binding:"required"`
-	Type     string `json:"type" binding:"required,oneof=Task Long Routine"`
-	Priority int    `json:"priority" binding:"min=1,max=3"`
-	Original string `json:"original"`
+	Title      string           `json:"title" binding:"required"`
+	Type       string           `json:"type" binding:"required,oneof=Task Long Routine"`
+	Priority   int              `json:"priority" binding:"min=1,max=3"`
+	Original   string           `json:"original"`
+	DueAt      *string          `json:"due_at"`
+	Recurrence *recurrence.Rule `json:"recurrence"`
+}
+
+type CreateSubtaskRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// ReorderRequest lists task IDs in their new order.
+type ReorderRequest struct {
+	IDs []int64 `json:"ids" binding:"required"`
 }
 
 type UpdateTaskRequest struct {
-	Title     *string `json:"title"`
-	Priority  *int    `json:"priority"`
-	Completed *bool   `json:"completed"`
+	Title      *string          `json:"title"`
+	Priority   *int             `json:"priority"`
+	Completed  *bool            `json:"completed"`
+	DueAt      NullableDate     `json:"due_at"`
+	Recurrence *recurrence.Rule `json:"recurrence"`
+}
+
+// NullableDate distinguishes an absent field from an explicit null, so a
+// PATCH can clear a due date with {"due_at": null}.
+type NullableDate struct {
+	Set   bool
+	Value *string
+}
+
+func (d *NullableDate) UnmarshalJSON(data []byte) error {
+	d.Set = true
+	if string(data) == "null" {
+		d.Value = nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	d.Value = &s
+	return nil
 }
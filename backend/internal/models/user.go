@@ -2,13 +2,23 @@ package models
 
 import "time"
 
+// Task list orderings a user can pick.
+const (
+	TaskOrderPriority = "priority"
+	TaskOrderManual   = "manual"
+)
+
 type User struct {
-	ID         int64     `json:"id"`
-	Username   string    `json:"username,omitempty"`
-	FirstName  string    `json:"first_name,omitempty"`
-	Language   string    `json:"language"`
-	Timezone   string    `json:"timezone"`
-	ThemeIndex int       `json:"theme_index"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID            int64     `json:"id"`
+	Username      string    `json:"username,omitempty"`
+	FirstName     string    `json:"first_name,omitempty"`
+	Language      string    `json:"language"`
+	Timezone      string    `json:"timezone"`
+	ThemeIndex    int       `json:"theme_index"`
+	DigestEnabled bool      `json:"digest_enabled"`
+	DigestHour    int       `json:"digest_hour"`
+	ReviewEnabled bool      `json:"review_enabled"`
+	TaskOrder     string    `json:"task_order"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
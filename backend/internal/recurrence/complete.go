@@ -0,0 +1,92 @@
+package recurrence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/timezone"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotRecurring is returned by Complete for tasks without a rule; callers
+// fall back to the regular one-off completion.
+var ErrNotRecurring = errors.New("task is not recurring")
+
+type Completion struct {
+	Title       string
+	Occurrence  time.Time // the local date that was completed
+	Next        time.Time // the next local date the routine is due
+	AlreadyDone bool      // today's occurrence was already completed
+}
+
+// Complete records today's occurrence of a routine in task_completions and
+// resets the task to its next occurrence, within tx. The task stays open; a
+// due time, if any, moves to the same local clock time on the next
+// occurrence.
+func Complete(ctx context.Context, tx pgx.Tx, userID, taskID int64, loc *time.Location) (*Completion, error) {
+	var title string
+	var rule *Rule
+	var next *time.Time
+	var dueAt *time.Time
+	err := tx.QueryRow(ctx, `
+		SELECT title, recurrence, next_occurrence, due_at FROM tasks
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, taskID, userID).Scan(&title, &rule, &next, &dueAt)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrNotRecurring
+	}
+
+	c, dueAt := rule.Advance(next, dueAt, loc)
+	c.Title = title
+	if c.AlreadyDone {
+		return &c, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO task_completions (task_id, user_id, occurrence) VALUES ($1, $2, $3)
+	`, taskID, userID, c.Occurrence.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE tasks
+		SET completed = FALSE, completed_at = NOW(), next_occurrence = $2,
+			due_at = $3, reminder_sent = FALSE, updated_at = NOW()
+		WHERE id = $1
+	`, taskID, c.Next.Format(time.DateOnly), dueAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Advance completes today's occurrence in loc given the pending occurrence
+// date and due time, returning the completion and the moved due time.
+// AlreadyDone is set, and nothing moves, when the pending occurrence is
+// still in the future.
+func (r Rule) Advance(pending, dueAt *time.Time, loc *time.Location) (Completion, *time.Time) {
+	today := timezone.Today(loc)
+	c := Completion{Occurrence: today}
+	if pending != nil {
+		p := time.Date(pending.Year(), pending.Month(), pending.Day(), 0, 0, 0, 0, loc)
+		if p.After(today) {
+			c.Next = p
+			c.AlreadyDone = true
+			return c, dueAt
+		}
+	}
+	c.Next = r.Next(today)
+
+	if dueAt != nil {
+		local := dueAt.In(loc)
+		moved := time.Date(c.Next.Year(), c.Next.Month(), c.Next.Day(), local.Hour(), local.Minute(), 0, 0, loc)
+		dueAt = &moved
+	}
+	return c, dueAt
+}
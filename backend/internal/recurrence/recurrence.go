@@ -0,0 +1,107 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/timezone"
+)
+
+// Frequencies supported by Rule.
+const (
+	Daily    = "daily"
+	Weekdays = "weekdays"
+	Interval = "interval"
+	Weekly   = "weekly"
+	Monthly  = "monthly"
+)
+
+// Rule describes when a routine repeats. Dates are local calendar dates in
+// the user's timezone.
+type Rule struct {
+	Freq     string `json:"freq"`
+	Interval int    `json:"interval,omitempty"`  // every N days, for "interval"
+	Weekdays []int  `json:"weekdays,omitempty"`  // ISO 1=Mon..7=Sun, for "weekly"
+	MonthDay int    `json:"month_day,omitempty"` // 1-31, for "monthly"; clamped to month end
+}
+
+func (r Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekdays:
+		return nil
+	case Interval:
+		if r.Interval < 1 || r.Interval > 365 {
+			return errors.New("interval must be 1-365 days")
+		}
+	case Weekly:
+		if len(r.Weekdays) == 0 {
+			return errors.New("weekly recurrence needs weekdays")
+		}
+		for _, d := range r.Weekdays {
+			if d < 1 || d > 7 {
+				return fmt.Errorf("invalid weekday %d (use 1=Mon..7=Sun)", d)
+			}
+		}
+	case Monthly:
+		if r.MonthDay < 1 || r.MonthDay > 31 {
+			return errors.New("month_day must be 1-31")
+		}
+	default:
+		return fmt.Errorf("unknown recurrence %q", r.Freq)
+	}
+	return nil
+}
+
+// First returns the first occurrence on or after day.
+func (r Rule) First(day time.Time) time.Time {
+	day = timezone.StartOfDay(day)
+	if r.Freq == Interval || r.matches(day) {
+		return day
+	}
+	return r.Next(day)
+}
+
+// Next returns the first occurrence strictly after day.
+func (r Rule) Next(day time.Time) time.Time {
+	day = timezone.StartOfDay(day)
+	if r.Freq == Interval {
+		return day.AddDate(0, 0, r.Interval)
+	}
+	// Every rule matches at least once within 62 days (monthly worst case).
+	for d := day.AddDate(0, 0, 1); d.Before(day.AddDate(0, 0, 63)); d = d.AddDate(0, 0, 1) {
+		if r.matches(d) {
+			return d
+		}
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+func (r Rule) matches(day time.Time) bool {
+	switch r.Freq {
+	case Daily:
+		return true
+	case Weekdays:
+		wd := day.Weekday()
+		return wd != time.Saturday && wd != time.Sunday
+	case Weekly:
+		iso := int(day.Weekday())
+		if iso == 0 {
+			iso = 7
+		}
+		for _, d := range r.Weekdays {
+			if d == iso {
+				return true
+			}
+		}
+		return false
+	case Monthly:
+		last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		want := r.MonthDay
+		if want > last {
+			want = last
+		}
+		return day.Day() == want
+	}
+	return false
+}
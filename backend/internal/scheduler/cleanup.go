@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+)
+
+// Telegram keeps undelivered updates for 24 hours, so older update_ids
+// can never be redelivered.
+const processedUpdateTTL = 48 * time.Hour
+
+func CleanupJob() Job {
+	return Job{
+		Name:     "processed-updates-cleanup",
+		Interval: time.Hour,
+		Run:      cleanupProcessedUpdates,
+	}
+}
+
+func cleanupProcessedUpdates(ctx context.Context) error {
+	tag, err := db.Pool.Exec(ctx, `
+		DELETE FROM processed_updates WHERE processed_at < $1
+	`, time.Now().Add(-processedUpdateTTL))
+	if err != nil {
+		return err
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("Cleanup: removed %d processed updates", n)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/bot"
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	DigestInterval = 5 * time.Minute
+	// digestWindow is how long after digest_hour a missed digest (e.g. during
+	// a restart) is still sent.
+	digestWindow = 3
+)
+
+func DigestJob() Job {
+	return Job{
+		Name:     "morning-digest",
+		Interval: DigestInterval,
+		Run:      sendMorningDigests,
+	}
+}
+
+type digestCandidate struct {
+	userID   int64
+	timezone string
+	hour     int
+}
+
+// sendMorningDigests sends each opted-in user one digest per local day, once
+// their local time reaches digest_hour. digest_sent_on is claimed before
+// sending so replicas never double-send.
+func sendMorningDigests(ctx context.Context) error {
+	// Local dates are at most a day ahead of UTC, so this skips users
+	// already served today without evaluating every timezone in SQL.
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, COALESCE(timezone, 'UTC'), COALESCE(digest_hour, 8) FROM users
+		WHERE digest_enabled AND (digest_sent_on IS NULL OR digest_sent_on <= CURRENT_DATE)
+	`)
+	if err != nil {
+		return err
+	}
+	var candidates []digestCandidate
+	for rows.Next() {
+		var c digestCandidate
+		if err := rows.Scan(&c.userID, &c.timezone, &c.hour); err != nil {
+			log.Printf("Digest scan error: %v", err)
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		loc := db.Location(c.timezone)
+		local := now.In(loc)
+		if local.Hour() < c.hour || local.Hour() >= c.hour+digestWindow {
+			continue
+		}
+
+		day := local.Format(time.DateOnly)
+		var language string
+		err := db.Pool.QueryRow(ctx, `
+			UPDATE users SET digest_sent_on = $2
+			WHERE id = $1 AND digest_enabled AND (digest_sent_on IS NULL OR digest_sent_on < $2)
+			RETURNING COALESCE(language, 'en')
+		`, c.userID, day).Scan(&language)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := bot.SendDigest(ctx, bot.Digest{UserID: c.userID, Language: language, Location: loc}); err != nil {
+			log.Printf("SendDigest error (user %d): %v", c.userID, err)
+			releaseDigest(ctx, c.userID, day, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseDigest handles a failed send the way releaseReminder does: a 403
+// turns the digest off, other 4xx errors are dropped, and anything else
+// hands today's claim back so a later run inside the window retries.
+func releaseDigest(ctx context.Context, userID int64, day string, sendErr error) {
+	var apiErr *bot.APIError
+	if errors.As(sendErr, &apiErr) && apiErr.Code == http.StatusForbidden {
+		// The user blocked the bot or never started it.
+		if _, err := db.Pool.Exec(ctx, `UPDATE users SET digest_enabled = FALSE WHERE id = $1`, userID); err != nil {
+			log.Printf("Digest disable error (user %d): %v", userID, err)
+		}
+		return
+	}
+	if !bot.Transient(sendErr) {
+		return
+	}
+	if _, err := db.Pool.Exec(ctx, `
+		UPDATE users SET digest_sent_on = $2::date - 1 WHERE id = $1 AND digest_sent_on = $2
+	`, userID, day); err != nil {
+		log.Printf("Digest release error (user %d): %v", userID, err)
+	}
+}
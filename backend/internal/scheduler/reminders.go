@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/bot"
+	"github.com/enkinvsh/focus-backend/internal/db"
+)
+
+const (
+	ReminderInterval  = 30 * time.Second
+	reminderBatchSize = 50
+)
+
+func ReminderJob() Job {
+	return Job{
+		Name:     "reminders",
+		Interval: ReminderInterval,
+		Run:      sendDueReminders,
+	}
+}
+
+// sendDueReminders claims due tasks by flipping reminder_sent in a single
+// UPDATE. FOR UPDATE SKIP LOCKED lets concurrent replicas claim disjoint
+// batches, so each reminder is delivered at most once. Users who blocked
+// the bot are skipped until they send /start again.
+func sendDueReminders(ctx context.Context) error {
+	rows, err := db.Pool.Query(ctx, `
+		UPDATE tasks t
+		SET reminder_sent = TRUE, updated_at = NOW()
+		FROM users u
+		WHERE t.id IN (
+			SELECT id FROM tasks
+			WHERE due_at <= NOW() AND NOT reminder_sent AND NOT completed AND archived_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM users b WHERE b.id = tasks.user_id AND b.reminders_blocked)
+			ORDER BY due_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		AND u.id = t.user_id
+		AND NOT t.reminder_sent
+		RETURNING t.id, t.user_id, t.title, COALESCE(u.language, 'en')
+	`, reminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var reminders []bot.Reminder
+	for rows.Next() {
+		var r bot.Reminder
+		if err := rows.Scan(&r.TaskID, &r.UserID, &r.Title, &r.Language); err != nil {
+			log.Printf("Reminder scan error: %v", err)
+			continue
+		}
+		reminders = append(reminders, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range reminders {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := bot.SendReminder(ctx, r); err != nil {
+			log.Printf("SendReminder error (task %d): %v", r.TaskID, err)
+			releaseReminder(ctx, r, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseReminder handles a failed send. A 403 means the user blocked the
+// bot, so their reminders stop; other 4xx errors won't succeed on retry.
+// Anything else is transient and the reminder is unclaimed for the next run.
+func releaseReminder(ctx context.Context, r bot.Reminder, sendErr error) {
+	var apiErr *bot.APIError
+	if errors.As(sendErr, &apiErr) && apiErr.Code == http.StatusForbidden {
+		if _, err := db.Pool.Exec(ctx, `UPDATE users SET reminders_blocked = TRUE WHERE id = $1`, r.UserID); err != nil {
+			log.Printf("Reminder block error (user %d): %v", r.UserID, err)
+		}
+		return
+	}
+	if !bot.Transient(sendErr) {
+		return
+	}
+	if _, err := db.Pool.Exec(ctx, `
+		UPDATE tasks SET reminder_sent = FALSE WHERE id = $1 AND NOT completed
+	`, r.TaskID); err != nil {
+		log.Printf("Reminder release error (task %d): %v", r.TaskID, err)
+	}
+}
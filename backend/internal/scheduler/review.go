@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/bot"
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ReviewInterval = 15 * time.Minute
+	// Weekly reviews go out on Sunday between 19:00 and 22:00 local time.
+	reviewWeekday = time.Sunday
+	reviewHour    = 19
+	reviewWindow  = 3
+)
+
+func ReviewJob() Job {
+	return Job{
+		Name:     "weekly-review",
+		Interval: ReviewInterval,
+		Run:      sendWeeklyReviews,
+	}
+}
+
+// sendWeeklyReviews mirrors sendMorningDigests: review_sent_on is claimed
+// per local Sunday before sending.
+func sendWeeklyReviews(ctx context.Context) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, COALESCE(timezone, 'UTC') FROM users
+		WHERE review_enabled AND (review_sent_on IS NULL OR review_sent_on <= CURRENT_DATE)
+	`)
+	if err != nil {
+		return err
+	}
+	type candidate struct {
+		userID   int64
+		timezone string
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.userID, &c.timezone); err != nil {
+			log.Printf("Review scan error: %v", err)
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		local := now.In(db.Location(c.timezone))
+		if local.Weekday() != reviewWeekday || local.Hour() < reviewHour || local.Hour() >= reviewHour+reviewWindow {
+			continue
+		}
+
+		day := local.Format(time.DateOnly)
+		var language string
+		err := db.Pool.QueryRow(ctx, `
+			UPDATE users SET review_sent_on = $2
+			WHERE id = $1 AND review_enabled AND (review_sent_on IS NULL OR review_sent_on < $2)
+			RETURNING COALESCE(language, 'en')
+		`, c.userID, day).Scan(&language)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := bot.SendReview(ctx, bot.Review{UserID: c.userID, Language: language}); err != nil {
+			log.Printf("SendReview error (user %d): %v", c.userID, err)
+			releaseReview(ctx, c.userID, day, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseReview mirrors releaseDigest for review_sent_on.
+func releaseReview(ctx context.Context, userID int64, day string, sendErr error) {
+	var apiErr *bot.APIError
+	if errors.As(sendErr, &apiErr) && apiErr.Code == http.StatusForbidden {
+		if _, err := db.Pool.Exec(ctx, `UPDATE users SET review_enabled = FALSE WHERE id = $1`, userID); err != nil {
+			log.Printf("Review disable error (user %d): %v", userID, err)
+		}
+		return
+	}
+	if !bot.Transient(sendErr) {
+		return
+	}
+	if _, err := db.Pool.Exec(ctx, `
+		UPDATE users SET review_sent_on = $2::date - 1 WHERE id = $1 AND review_sent_on = $2
+	`, userID, day); err != nil {
+		log.Printf("Review release error (user %d): %v", userID, err)
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultDueHour is the local hour used for due dates given as a bare date.
+const DefaultDueHour = 9
+
+// maxDueAhead bounds how far in the future a parsed due date may be.
+const maxDueAhead = 2 * 365 * 24 * time.Hour
+
+var ErrInvalidDueAt = errors.New("invalid due_at (use RFC3339 or YYYY-MM-DD)")
+
+// ParseDueAt accepts an RFC3339 timestamp, or a local date/datetime that is
+// interpreted in loc. A bare date resolves to DefaultDueHour local time.
+func ParseDueAt(raw string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", raw, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, raw, loc); err == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), DefaultDueHour, 0, 0, 0, loc), nil
+	}
+	return time.Time{}, ErrInvalidDueAt
+}
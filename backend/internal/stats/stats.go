@@ -0,0 +1,303 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+)
+
+const (
+	DefaultDays = 30
+	MaxDays     = 366
+)
+
+type DayCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type WeekCount struct {
+	WeekStart string `json:"week_start"`
+	Count     int    `json:"count"`
+}
+
+type CompletionRate struct {
+	Key       string  `json:"key"`
+	Total     int     `json:"total"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+type Stats struct {
+	Timezone           string           `json:"timezone"`
+	From               string           `json:"from"`
+	To                 string           `json:"to"`
+	PerDay             []DayCount       `json:"completions_per_day"`
+	PerWeek            []WeekCount      `json:"completions_per_week"`
+	RateByType         []CompletionRate `json:"completion_rate_by_type"`
+	RateByPriority     []CompletionRate `json:"completion_rate_by_priority"`
+	AvgCompletionHours *float64         `json:"avg_completion_hours"`
+	CurrentStreak      int              `json:"current_streak"`
+	Breathing          BreathingStats   `json:"breathing"`
+}
+
+// BreathingStats counts sessions in the range; the streak counts local days
+// with at least one session that wasn't aborted.
+type BreathingStats struct {
+	Sessions          int     `json:"sessions"`
+	CompletedSessions int     `json:"completed_sessions"`
+	Cycles            int     `json:"cycles"`
+	Minutes           float64 `json:"minutes"`
+	CurrentStreak     int     `json:"current_streak"`
+}
+
+// Activity is the raw data stats are computed from. Build loads it from
+// Postgres; the in-memory store assembles it from its maps.
+type Activity struct {
+	// Completions holds every completion: closed top-level tasks plus each
+	// logged routine occurrence.
+	Completions []time.Time
+	// Tasks holds the top-level tasks created or completed in the range.
+	Tasks    []Task
+	Sessions []Session
+}
+
+type Task struct {
+	Type        string
+	Priority    int
+	Completed   bool
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+type Session struct {
+	StartedAt time.Time
+	EndedAt   time.Time
+	Cycles    int
+	Aborted   bool
+}
+
+// Since returns how far back Activity must reach for [from, to] and the
+// streaks ending at today.
+func Since(from, today time.Time) time.Time {
+	if streak := today.AddDate(0, 0, -MaxDays); streak.Before(from) {
+		return streak
+	}
+	return from
+}
+
+// Build aggregates the user's activity over the local dates [from, to],
+// bucketed in loc. today anchors the streaks.
+func Build(ctx context.Context, q db.Querier, userID int64, loc *time.Location, from, to, today time.Time) (*Stats, error) {
+	since := Since(from, today)
+	end := to.AddDate(0, 0, 1)
+	var a Activity
+
+	// Routines stay open, so their completions come from task_completions.
+	// Subtasks are steps of their parent and don't count on their own.
+	rows, err := q.Query(ctx, `
+		SELECT completed_at FROM tasks
+		WHERE user_id = $1 AND completed AND parent_id IS NULL AND completed_at >= $2
+		UNION ALL
+		SELECT completed_at FROM task_completions WHERE user_id = $1 AND completed_at >= $2
+	`, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var at time.Time
+		if err := rows.Scan(&at); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		a.Completions = append(a.Completions, at)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT task_type, priority, completed, created_at, completed_at
+		FROM tasks
+		WHERE user_id = $1 AND parent_id IS NULL
+			AND ((created_at >= $2 AND created_at < $3) OR (completed AND completed_at >= $2 AND completed_at < $3))
+	`, userID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.Type, &t.Priority, &t.Completed, &t.CreatedAt, &t.CompletedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		a.Tasks = append(a.Tasks, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT started_at, ended_at, cycles, aborted
+		FROM breathing_sessions
+		WHERE user_id = $1 AND started_at >= $2
+	`, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b Session
+		if err := rows.Scan(&b.StartedAt, &b.EndedAt, &b.Cycles, &b.Aborted); err != nil {
+			return nil, err
+		}
+		a.Sessions = append(a.Sessions, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return Compute(a, loc, from, to, today), nil
+}
+
+// Compute aggregates a over the local dates [from, to], bucketed in loc.
+// today anchors the streaks, which count back at most MaxDays.
+func Compute(a Activity, loc *time.Location, from, to, today time.Time) *Stats {
+	end := to.AddDate(0, 0, 1)
+	inRange := func(t time.Time) bool { return !t.Before(from) && t.Before(end) }
+	streakSince := today.AddDate(0, 0, -MaxDays)
+
+	// Completions per local day, zero-filled.
+	counts := map[string]int{}
+	var streakDays []time.Time
+	for _, at := range a.Completions {
+		if inRange(at) {
+			counts[at.In(loc).Format(time.DateOnly)]++
+		}
+		if !at.Before(streakSince) {
+			streakDays = append(streakDays, at)
+		}
+	}
+	stats := &Stats{
+		Timezone: loc.String(),
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format(time.DateOnly)
+		stats.PerDay = append(stats.PerDay, DayCount{Date: key, Count: counts[key]})
+
+		// Weeks start on Monday.
+		weekStart := d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7)).Format(time.DateOnly)
+		if n := len(stats.PerWeek); n == 0 || stats.PerWeek[n-1].WeekStart != weekStart {
+			stats.PerWeek = append(stats.PerWeek, WeekCount{WeekStart: weekStart})
+		}
+		stats.PerWeek[len(stats.PerWeek)-1].Count += counts[key]
+	}
+	stats.CurrentStreak = StreakFrom(localDays(streakDays, loc), today)
+
+	// Completion rates over top-level tasks created in the range, and the
+	// average time to close those completed in it.
+	byType := map[string]*CompletionRate{}
+	byPriority := map[string]*CompletionRate{}
+	var hours float64
+	var closed int
+	for _, t := range a.Tasks {
+		if inRange(t.CreatedAt) {
+			addRate(byType, t.Type, t.Completed)
+			addRate(byPriority, strconv.Itoa(t.Priority), t.Completed)
+		}
+		if t.Completed && t.CompletedAt != nil && inRange(*t.CompletedAt) {
+			hours += t.CompletedAt.Sub(t.CreatedAt).Hours()
+			closed++
+		}
+	}
+	stats.RateByType = sortedRates(byType)
+	stats.RateByPriority = sortedRates(byPriority)
+	if closed > 0 {
+		avg := hours / float64(closed)
+		stats.AvgCompletionHours = &avg
+	}
+
+	// The breathing streak counts days with a session that wasn't aborted.
+	var breathed []time.Time
+	for _, b := range a.Sessions {
+		if inRange(b.StartedAt) {
+			stats.Breathing.Sessions++
+			if !b.Aborted {
+				stats.Breathing.CompletedSessions++
+			}
+			stats.Breathing.Cycles += b.Cycles
+			stats.Breathing.Minutes += b.EndedAt.Sub(b.StartedAt).Minutes()
+		}
+		if !b.Aborted && !b.StartedAt.Before(streakSince) {
+			breathed = append(breathed, b.StartedAt)
+		}
+	}
+	stats.Breathing.CurrentStreak = StreakFrom(localDays(breathed, loc), today)
+
+	return stats
+}
+
+func addRate(rates map[string]*CompletionRate, key string, completed bool) {
+	r, ok := rates[key]
+	if !ok {
+		r = &CompletionRate{Key: key}
+		rates[key] = r
+	}
+	r.Total++
+	if completed {
+		r.Completed++
+	}
+	r.Rate = float64(r.Completed) / float64(r.Total)
+}
+
+func sortedRates(rates map[string]*CompletionRate) []CompletionRate {
+	result := []CompletionRate{}
+	for _, r := range rates {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// localDays returns the distinct local dates of times, newest first.
+func localDays(times []time.Time, loc *time.Location) []string {
+	seen := map[string]bool{}
+	var days []string
+	for _, t := range times {
+		day := t.In(loc).Format(time.DateOnly)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	return days
+}
+
+// StreakFrom counts consecutive days in days (sorted newest first,
+// YYYY-MM-DD) that end today or yesterday.
+func StreakFrom(days []string, today time.Time) int {
+	if len(days) == 0 {
+		return 0
+	}
+	expect := today
+	if days[0] != today.Format(time.DateOnly) {
+		expect = today.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for _, day := range days {
+		if day != expect.Format(time.DateOnly) {
+			break
+		}
+		streak++
+		expect = expect.AddDate(0, 0, -1)
+	}
+	return streak
+}
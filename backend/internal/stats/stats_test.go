@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	day := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, loc) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	from, to, today := day(2, 0), day(8, 0), day(8, 0) // Monday to Sunday
+	a := Activity{
+		Completions: []time.Time{
+			day(1, 12), // before the range
+			day(6, 9),
+			day(6, 23),
+			day(7, 0).Add(-time.Minute),    // still the 6th locally
+			day(8, 0).Add(-25 * time.Hour), // the 6th again
+			day(7, 10),
+			day(8, 8),
+		},
+		Tasks: []Task{
+			{Type: "Task", Priority: 1, Completed: true, CreatedAt: day(6, 7), CompletedAt: ptr(day(6, 9))},
+			{Type: "Task", Priority: 2, CreatedAt: day(3, 9)},
+			{Type: "Long", Priority: 1, Completed: true, CreatedAt: day(1, 9), CompletedAt: ptr(day(7, 13))},
+		},
+		Sessions: []Session{
+			{StartedAt: day(7, 8), EndedAt: day(7, 8).Add(5 * time.Minute), Cycles: 4},
+			{StartedAt: day(8, 8), EndedAt: day(8, 8).Add(90 * time.Second), Cycles: 1, Aborted: true},
+		},
+	}
+
+	got := Compute(a, loc, from, to, today)
+
+	counts := []int{0, 0, 0, 0, 4, 1, 1}
+	for i, c := range got.PerDay {
+		if c.Count != counts[i] {
+			t.Errorf("PerDay[%s] = %d, want %d", c.Date, c.Count, counts[i])
+		}
+	}
+	if len(got.PerWeek) != 1 || got.PerWeek[0] != (WeekCount{WeekStart: "2026-03-02", Count: 6}) {
+		t.Errorf("PerWeek = %+v", got.PerWeek)
+	}
+	if got.CurrentStreak != 3 {
+		t.Errorf("CurrentStreak = %d, want 3", got.CurrentStreak)
+	}
+	wantType := []CompletionRate{{Key: "Task", Total: 2, Completed: 1, Rate: 0.5}}
+	if !reflect.DeepEqual(got.RateByType, wantType) {
+		t.Errorf("RateByType = %+v, want %+v", got.RateByType, wantType)
+	}
+	wantPriority := []CompletionRate{{Key: "1", Total: 1, Completed: 1, Rate: 1}, {Key: "2", Total: 1}}
+	if !reflect.DeepEqual(got.RateByPriority, wantPriority) {
+		t.Errorf("RateByPriority = %+v, want %+v", got.RateByPriority, wantPriority)
+	}
+	// 2h for the Task, 148h for the Long task created before the range.
+	if got.AvgCompletionHours == nil || *got.AvgCompletionHours != 75 {
+		t.Errorf("AvgCompletionHours = %v, want 75", got.AvgCompletionHours)
+	}
+	wantBreathing := BreathingStats{Sessions: 2, CompletedSessions: 1, Cycles: 5, Minutes: 6.5, CurrentStreak: 1}
+	if got.Breathing != wantBreathing {
+		t.Errorf("Breathing = %+v, want %+v", got.Breathing, wantBreathing)
+	}
+}
+
+func TestStreakFrom(t *testing.T) {
+	today := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		days []string
+		want int
+	}{
+		{"none", nil, 0},
+		{"ends today", []string{"2026-03-08", "2026-03-07"}, 2},
+		{"ends yesterday", []string{"2026-03-07", "2026-03-06", "2026-03-04"}, 2},
+		{"broken", []string{"2026-03-06"}, 0},
+	}
+	for _, tt := range tests {
+		if got := StreakFrom(tt.days, today); got != tt.want {
+			t.Errorf("%s: StreakFrom = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,627 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/enkinvsh/focus-backend/internal/recurrence"
+	"github.com/enkinvsh/focus-backend/internal/stats"
+)
+
+// Memory implements TaskStore, UserStore and ActivityStore in process
+// memory, for tests and local experiments. It mirrors the Postgres
+// semantics the API relies on, not every database constraint.
+type Memory struct {
+	mu          sync.Mutex
+	nextID      int64
+	tasks       map[int64]*models.Task
+	users       map[int64]*models.User
+	events      []userEvent
+	breathing   []userSession
+	completions []userCompletion // routine occurrences, like task_completions
+}
+
+type userEvent struct {
+	userID int64
+	events.Event
+}
+
+type userSession struct {
+	userID int64
+	models.BreathingSession
+}
+
+type userCompletion struct {
+	userID      int64
+	completedAt time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		tasks: map[int64]*models.Task{},
+		users: map[int64]*models.User{},
+	}
+}
+
+func (m *Memory) ListTasks(ctx context.Context, userID int64, f TaskFilter) ([]models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks := []models.Task{}
+	for _, t := range m.tasks {
+		if t.UserID == userID && t.ParentID == nil && t.TaskType == f.Type && t.Completed == f.Completed &&
+			(t.ArchivedAt != nil) == f.Archived {
+			tasks = append(tasks, m.withProgress(t))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return taskLess(&tasks[i], &tasks[j], f.Manual) })
+
+	if f.Offset >= len(tasks) {
+		return []models.Task{}, nil
+	}
+	tasks = tasks[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(tasks) {
+		tasks = tasks[:f.Limit]
+	}
+	return tasks, nil
+}
+
+func (m *Memory) GetTask(ctx context.Context, userID, taskID int64) (*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, ErrNotFound
+	}
+	cp := m.withProgress(t)
+	return &cp, nil
+}
+
+func (m *Memory) CreateTask(ctx context.Context, t *models.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	now := time.Now()
+	t.ID = m.nextID
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	cp := *t
+	m.tasks[t.ID] = &cp
+	return nil
+}
+
+func (m *Memory) UpdateTask(ctx context.Context, userID, taskID int64, u TaskUpdate) (*TaskChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, ErrNotFound
+	}
+	change := TaskChange{WasCompleted: t.Completed}
+
+	if u.Title != nil {
+		t.Title = *u.Title
+	}
+	if u.Priority != nil {
+		t.Priority = *u.Priority
+	}
+	if u.Completed != nil {
+		t.Completed = *u.Completed
+		t.CompletedAt = nil
+		if *u.Completed {
+			now := time.Now()
+			t.CompletedAt = &now
+		}
+	}
+	if u.DueSet {
+		if !sameTime(t.DueAt, u.DueAt) {
+			t.ReminderSent = false
+		}
+		t.DueAt = u.DueAt
+	}
+	if u.Recurrence != nil {
+		t.Recurrence = u.Recurrence
+	}
+	if u.NextOccurrence != nil {
+		t.NextOccurrence = u.NextOccurrence
+	}
+	t.UpdatedAt = time.Now()
+
+	change.Title = t.Title
+	if t.ParentID != nil && t.Completed != change.WasCompleted {
+		change.Parent = m.syncParent(*t.ParentID)
+	}
+	return &change, nil
+}
+
+func (m *Memory) CompleteRoutine(ctx context.Context, userID, taskID int64, loc *time.Location) (*recurrence.Completion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, ErrNotFound
+	}
+	if t.Recurrence == nil {
+		return nil, recurrence.ErrNotRecurring
+	}
+
+	var pending *time.Time
+	if t.NextOccurrence != nil {
+		if p, err := time.ParseInLocation(time.DateOnly, *t.NextOccurrence, loc); err == nil {
+			pending = &p
+		}
+	}
+	c, dueAt := t.Recurrence.Advance(pending, t.DueAt, loc)
+	c.Title = t.Title
+	if c.AlreadyDone {
+		return &c, nil
+	}
+
+	now := time.Now()
+	next := c.Next.Format(time.DateOnly)
+	m.completions = append(m.completions, userCompletion{userID: userID, completedAt: now})
+	t.Completed = false
+	t.CompletedAt = &now
+	t.NextOccurrence = &next
+	t.DueAt = dueAt
+	t.ReminderSent = false
+	t.UpdatedAt = now
+	return &c, nil
+}
+
+func (m *Memory) DeleteTask(ctx context.Context, userID, taskID int64) (*TaskChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, ErrNotFound
+	}
+	delete(m.tasks, taskID)
+	for id, sub := range m.tasks {
+		if sub.ParentID != nil && *sub.ParentID == taskID {
+			delete(m.tasks, id)
+		}
+	}
+
+	change := TaskChange{Title: t.Title, WasCompleted: t.Completed}
+	if t.ParentID != nil {
+		change.Parent = m.syncParent(*t.ParentID)
+	}
+	return &change, nil
+}
+
+func (m *Memory) ReorderTasks(ctx context.Context, userID int64, ids []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(ids) == 0 {
+		return nil
+	}
+	if duplicateIDs(ids) {
+		return ErrInvalidOrder
+	}
+	var first *models.Task
+	for _, id := range ids {
+		t, ok := m.tasks[id]
+		if !ok || t.UserID != userID || t.ParentID != nil {
+			return ErrNotFound
+		}
+		if first == nil {
+			first = t
+		} else if !sameList(first, t) {
+			return ErrInvalidOrder
+		}
+	}
+
+	var list []*models.Task
+	for _, t := range m.tasks {
+		if t.UserID == userID && t.ParentID == nil && sameList(first, t) {
+			list = append(list, t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return taskLess(list[i], list[j], true) })
+	order := make([]int64, len(list))
+	current := map[int64]*float64{}
+	for i, t := range list {
+		order[i] = t.ID
+		current[t.ID] = t.Position
+	}
+
+	now := time.Now()
+	for id, pos := range reorderPositions(mergeOrder(order, ids), current) {
+		pos := pos
+		m.tasks[id].Position = &pos
+		m.tasks[id].UpdatedAt = now
+	}
+	return nil
+}
+
+// sameList reports whether a and b are shown in the same task list.
+func sameList(a, b *models.Task) bool {
+	return a.TaskType == b.TaskType && a.Completed == b.Completed && (a.ArchivedAt != nil) == (b.ArchivedAt != nil)
+}
+
+// taskLess orders tasks like ListTasks: by priority, or in manual mode by
+// position with tasks that were never moved first.
+func taskLess(a, b *models.Task, manual bool) bool {
+	if manual && !samePosition(a.Position, b.Position) {
+		if a.Position == nil || b.Position == nil {
+			return a.Position == nil
+		}
+		return *a.Position < *b.Position
+	}
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func (m *Memory) ListSubtasks(ctx context.Context, userID, parentID int64) ([]models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.tasks[parentID]; !ok || p.UserID != userID {
+		return nil, ErrNotFound
+	}
+	tasks := []models.Task{}
+	for _, t := range m.subtasks(parentID) {
+		tasks = append(tasks, *t)
+	}
+	return tasks, nil
+}
+
+func (m *Memory) CreateSubtask(ctx context.Context, t *models.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.tasks[*t.ParentID]; !ok || p.UserID != t.UserID {
+		return ErrNotFound
+	}
+	pos := 1.0
+	for _, sub := range m.subtasks(*t.ParentID) {
+		if sub.Position != nil && *sub.Position >= pos {
+			pos = *sub.Position + 1
+		}
+	}
+
+	m.nextID++
+	now := time.Now()
+	t.ID = m.nextID
+	t.Position = &pos
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	cp := *t
+	m.tasks[t.ID] = &cp
+	m.syncParent(*t.ParentID)
+	return nil
+}
+
+func (m *Memory) ReorderSubtasks(ctx context.Context, userID, parentID int64, ids []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.tasks[parentID]; !ok || p.UserID != userID {
+		return ErrNotFound
+	}
+	var current []int64
+	for _, t := range m.subtasks(parentID) {
+		current = append(current, t.ID)
+	}
+	if !sameIDs(current, ids) {
+		return ErrInvalidOrder
+	}
+
+	now := time.Now()
+	for i, id := range ids {
+		pos := float64(i + 1)
+		m.tasks[id].Position = &pos
+		m.tasks[id].UpdatedAt = now
+	}
+	return nil
+}
+
+// syncParent completes the parent once all of its subtasks are done, and
+// reopens it while any is open. The caller holds m.mu.
+func (m *Memory) syncParent(parentID int64) *ParentChange {
+	p, ok := m.tasks[parentID]
+	subs := m.subtasks(parentID)
+	if !ok || len(subs) == 0 {
+		return nil
+	}
+	done := true
+	for _, sub := range subs {
+		done = done && sub.Completed
+	}
+	if p.Completed == done {
+		return nil
+	}
+
+	now := time.Now()
+	p.Completed = done
+	p.CompletedAt = nil
+	if done {
+		p.CompletedAt = &now
+	}
+	p.UpdatedAt = now
+	return &ParentChange{ID: p.ID, Title: p.Title, Completed: done}
+}
+
+// subtasks returns the parent's subtasks in checklist order. The caller
+// holds m.mu.
+func (m *Memory) subtasks(parentID int64) []*models.Task {
+	var subs []*models.Task
+	for _, t := range m.tasks {
+		if t.ParentID != nil && *t.ParentID == parentID {
+			subs = append(subs, t)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		pi, pj := position(subs[i]), position(subs[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs
+}
+
+// withProgress copies t with its subtask counts filled in. The caller holds
+// m.mu.
+func (m *Memory) withProgress(t *models.Task) models.Task {
+	cp := *t
+	var total, done int
+	for _, sub := range m.subtasks(t.ID) {
+		total++
+		if sub.Completed {
+			done++
+		}
+	}
+	cp.SetProgress(total, done)
+	return cp
+}
+
+func position(t *models.Task) float64 {
+	if t.Position == nil {
+		return 0
+	}
+	return *t.Position
+}
+
+func (m *Memory) EnsureUser(ctx context.Context, p db.Profile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[p.ID]; ok {
+		u.FirstName = p.FirstName
+		u.Username = p.Username
+		return nil
+	}
+	u := DefaultPreferences()
+	u.ID = p.ID
+	u.FirstName = p.FirstName
+	u.Username = p.Username
+	u.Language = db.Language(p.LanguageCode)
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	m.users[p.ID] = &u
+	return nil
+}
+
+func (m *Memory) Location(ctx context.Context, userID int64) *time.Location {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[userID]; ok {
+		return db.Location(u.Timezone)
+	}
+	return time.UTC
+}
+
+func (m *Memory) Preferences(ctx context.Context, userID int64) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[userID]; ok {
+		cp := *u
+		return &cp, nil
+	}
+	u := DefaultPreferences()
+	u.ID = userID
+	return &u, nil
+}
+
+func (m *Memory) UpdatePreferences(ctx context.Context, p db.Profile, u PreferencesUpdate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[p.ID]
+	if !ok {
+		d := DefaultPreferences()
+		d.ID = p.ID
+		d.FirstName = p.FirstName
+		d.Username = p.Username
+		d.CreatedAt = time.Now()
+		user = &d
+		m.users[p.ID] = user
+	}
+	if u.Language != nil {
+		user.Language = *u.Language
+	}
+	if u.Timezone != nil {
+		user.Timezone = *u.Timezone
+	}
+	if u.ThemeIndex != nil {
+		user.ThemeIndex = *u.ThemeIndex
+	}
+	if u.DigestEnabled != nil {
+		user.DigestEnabled = *u.DigestEnabled
+	}
+	if u.DigestHour != nil {
+		user.DigestHour = *u.DigestHour
+	}
+	if u.ReviewEnabled != nil {
+		user.ReviewEnabled = *u.ReviewEnabled
+	}
+	if u.TaskOrder != nil {
+		user.TaskOrder = *u.TaskOrder
+	}
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// sameIDs reports whether ids lists exactly the IDs in current.
+func sameIDs(current, ids []int64) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	seen := make(map[int64]bool, len(current))
+	for _, id := range current {
+		seen[id] = true
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
+
+func (m *Memory) RecordEvent(ctx context.Context, userID int64, eventType string, metadata map[string]interface{}) {
+	var raw json.RawMessage
+	if metadata != nil {
+		var err error
+		if raw, err = json.Marshal(metadata); err != nil {
+			return
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	m.events = append(m.events, userEvent{userID: userID, Event: events.Event{
+		ID: m.nextID, Type: eventType, Metadata: raw, CreatedAt: time.Now(),
+	}})
+}
+
+func (m *Memory) ListEvents(ctx context.Context, userID int64, types []string, before int64, limit int) ([]events.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, t := range types {
+		wanted[t] = true
+	}
+	result := []events.Event{}
+	for i := len(m.events) - 1; i >= 0 && len(result) < limit; i-- {
+		e := m.events[i]
+		if e.userID != userID || (before != 0 && e.ID >= before) || (len(wanted) > 0 && !wanted[e.Type]) {
+			continue
+		}
+		result = append(result, e.Event)
+	}
+	return result, nil
+}
+
+func (m *Memory) CreateBreathingSession(ctx context.Context, userID int64, b *models.BreathingSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	b.ID = m.nextID
+	b.CreatedAt = time.Now()
+	m.breathing = append(m.breathing, userSession{userID: userID, BreathingSession: *b})
+	return nil
+}
+
+func (m *Memory) ListBreathingSessions(ctx context.Context, userID int64, limit, offset int) ([]models.BreathingSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.BreathingSession
+	for _, b := range m.breathing {
+		if b.userID == userID {
+			list = append(list, b.BreathingSession)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].StartedAt.After(list[j].StartedAt) })
+
+	sessions := []models.BreathingSession{}
+	for i := offset; i < len(list) && len(sessions) < limit; i++ {
+		sessions = append(sessions, list[i])
+	}
+	return sessions, nil
+}
+
+func (m *Memory) Stats(ctx context.Context, userID int64, loc *time.Location, from, to, today time.Time) (*stats.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	since := stats.Since(from, today)
+	end := to.AddDate(0, 0, 1)
+	var a stats.Activity
+	for _, t := range m.tasks {
+		if t.UserID != userID || t.ParentID != nil {
+			continue
+		}
+		closed := t.Completed && t.CompletedAt != nil
+		if closed && !t.CompletedAt.Before(since) {
+			a.Completions = append(a.Completions, *t.CompletedAt)
+		}
+		created := !t.CreatedAt.Before(from) && t.CreatedAt.Before(end)
+		if created || (closed && !t.CompletedAt.Before(from) && t.CompletedAt.Before(end)) {
+			a.Tasks = append(a.Tasks, stats.Task{Type: t.TaskType, Priority: t.Priority, Completed: t.Completed,
+				CreatedAt: t.CreatedAt, CompletedAt: t.CompletedAt})
+		}
+	}
+	for _, c := range m.completions {
+		if c.userID == userID && !c.completedAt.Before(since) {
+			a.Completions = append(a.Completions, c.completedAt)
+		}
+	}
+	for _, b := range m.breathing {
+		if b.userID == userID && !b.StartedAt.Before(since) {
+			a.Sessions = append(a.Sessions, stats.Session{StartedAt: b.StartedAt, EndedAt: b.EndedAt,
+				Cycles: b.Cycles, Aborted: b.Aborted})
+		}
+	}
+	return stats.Compute(a, loc, from, to, today), nil
+}
+
+func samePosition(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+var (
+	_ TaskStore     = (*Memory)(nil)
+	_ UserStore     = (*Memory)(nil)
+	_ ActivityStore = (*Memory)(nil)
+	_ TaskStore     = (*Postgres)(nil)
+	_ UserStore     = (*Postgres)(nil)
+	_ ActivityStore = (*Postgres)(nil)
+)
@@ -0,0 +1,127 @@
+package store
+
+const (
+	// positionGap spaces out positions so later moves fit in between.
+	positionGap = 1024.0
+	// minPositionGap is the closest two positions may get before the list
+	// is renumbered.
+	minPositionGap = 1e-6
+)
+
+// reorderPositions returns new positions for the tasks in ids, which lists
+// them in their new order; current holds their positions, if any. Tasks
+// already in the right relative order keep their position and the others
+// are placed in the gaps around them, so moving one task rewrites one row.
+// When a gap runs out, every listed task is renumbered.
+func reorderPositions(ids []int64, current map[int64]*float64) map[int64]float64 {
+	n := len(ids)
+	pos := make([]float64, n)
+	known := make([]bool, n)
+	for i, id := range ids {
+		if p := current[id]; p != nil {
+			pos[i], known[i] = *p, true
+		}
+	}
+	keep := increasingRun(pos, known)
+
+	updates := map[int64]float64{}
+	prev := -1 // index of the last kept task
+	for i := 0; i <= n; i++ {
+		if i < n && !keep[i] {
+			continue
+		}
+		// Place ids[prev+1 : i] between the kept tasks at prev and i.
+		count := i - prev - 1
+		if count > 0 {
+			var lo, step float64
+			switch {
+			case prev < 0 && i == n:
+				lo, step = 0, positionGap
+			case prev < 0:
+				lo, step = pos[i]-positionGap*float64(count+1), positionGap
+			case i == n:
+				lo, step = pos[prev], positionGap
+			default:
+				lo, step = pos[prev], (pos[i]-pos[prev])/float64(count+1)
+			}
+			if step < minPositionGap {
+				return renumber(ids)
+			}
+			for j := 1; j <= count; j++ {
+				updates[ids[prev+j]] = lo + step*float64(j)
+			}
+		}
+		prev = i
+	}
+	return updates
+}
+
+// increasingRun marks the longest run of known positions that is already
+// strictly increasing in list order; those tasks don't need to move.
+func increasingRun(pos []float64, known []bool) []bool {
+	n := len(pos)
+	length := make([]int, n)
+	from := make([]int, n)
+	best := -1
+	for i := range pos {
+		from[i] = -1
+		if !known[i] {
+			continue
+		}
+		length[i] = 1
+		for j := 0; j < i; j++ {
+			if known[j] && pos[j] < pos[i] && length[j]+1 > length[i] {
+				length[i], from[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+
+	keep := make([]bool, n)
+	for i := best; i >= 0; i = from[i] {
+		keep[i] = true
+	}
+	return keep
+}
+
+func renumber(ids []int64) map[int64]float64 {
+	updates := make(map[int64]float64, len(ids))
+	for i, id := range ids {
+		updates[id] = positionGap * float64(i+1)
+	}
+	return updates
+}
+
+// mergeOrder applies a partial reorder to a whole list: the tasks in ids
+// take the slots they already occupy in list, in the order of ids, and the
+// tasks the client didn't send stay where they are.
+func mergeOrder(list, ids []int64) []int64 {
+	listed := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+	}
+	merged := make([]int64, 0, len(list))
+	next := 0
+	for _, id := range list {
+		if listed[id] {
+			id = ids[next]
+			next++
+		}
+		merged = append(merged, id)
+	}
+	return merged
+}
+
+// duplicateIDs reports whether ids lists any task twice.
+func duplicateIDs(ids []int64) bool {
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/enkinvsh/focus-backend/internal/models"
+)
+
+func pos(v float64) *float64 { return &v }
+
+func TestReorderPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []int64
+		current map[int64]*float64
+		want    map[int64]float64
+	}{
+		{
+			name:    "no-op",
+			ids:     []int64{1, 2, 3},
+			current: map[int64]*float64{1: pos(1024), 2: pos(2048), 3: pos(3072)},
+			want:    map[int64]float64{},
+		},
+		{
+			name:    "move to front",
+			ids:     []int64{3, 1, 2},
+			current: map[int64]*float64{1: pos(1024), 2: pos(2048), 3: pos(3072)},
+			want:    map[int64]float64{3: 0},
+		},
+		{
+			name:    "move to middle",
+			ids:     []int64{1, 4, 2, 3},
+			current: map[int64]*float64{1: pos(1024), 2: pos(2048), 3: pos(3072), 4: pos(4096)},
+			want:    map[int64]float64{4: 1536},
+		},
+		{
+			name:    "move to end",
+			ids:     []int64{2, 3, 1},
+			current: map[int64]*float64{1: pos(1024), 2: pos(2048), 3: pos(3072)},
+			want:    map[int64]float64{1: 4096},
+		},
+		{
+			name:    "all unknown",
+			ids:     []int64{3, 1, 2},
+			current: map[int64]*float64{1: nil, 2: nil},
+			want:    map[int64]float64{3: 1024, 1: 2048, 2: 3072},
+		},
+		{
+			name:    "unknown placed around known",
+			ids:     []int64{4, 1, 5, 2},
+			current: map[int64]*float64{1: pos(1024), 2: pos(2048)},
+			want:    map[int64]float64{4: 0, 5: 1536},
+		},
+		{
+			name:    "gap exhausted",
+			ids:     []int64{1, 4, 2, 3},
+			current: map[int64]*float64{1: pos(1), 2: pos(1 + 1e-7), 3: pos(2), 4: pos(3)},
+			want:    map[int64]float64{1: 1024, 4: 2048, 2: 3072, 3: 4096},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reorderPositions(tt.ids, tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reorderPositions(%v) = %v, want %v", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncreasingRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		pos   []float64
+		known []bool
+		want  []bool
+	}{
+		{"empty", nil, nil, []bool{}},
+		{"sorted", []float64{1, 2, 3}, []bool{true, true, true}, []bool{true, true, true}},
+		{"one moved", []float64{3, 1, 2}, []bool{true, true, true}, []bool{false, true, true}},
+		{"skips unknown", []float64{1, 0, 2}, []bool{true, false, true}, []bool{true, false, true}},
+		{"all unknown", []float64{0, 0}, []bool{false, false}, []bool{false, false}},
+		{"equal is not increasing", []float64{1, 1}, []bool{true, true}, []bool{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := increasingRun(tt.pos, tt.known)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("increasingRun(%v) = %v, want %v", tt.pos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	got := renumber([]int64{7, 3, 5})
+	want := map[int64]float64{7: 1024, 3: 2048, 5: 3072}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renumber = %v, want %v", got, want)
+	}
+}
+
+func TestMergeOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		list []int64
+		ids  []int64
+		want []int64
+	}{
+		{"whole list", []int64{1, 2, 3}, []int64{3, 1, 2}, []int64{3, 1, 2}},
+		{"subset keeps others in place", []int64{1, 2, 3, 4}, []int64{3, 1}, []int64{3, 2, 1, 4}},
+		{"single id", []int64{1, 2, 3}, []int64{2}, []int64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeOrder(tt.list, tt.ids)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeOrder(%v, %v) = %v, want %v", tt.list, tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateIDs(t *testing.T) {
+	if duplicateIDs([]int64{1, 2, 3}) {
+		t.Error("duplicateIDs reported a duplicate in distinct ids")
+	}
+	if !duplicateIDs([]int64{1, 2, 1}) {
+		t.Error("duplicateIDs missed a duplicate")
+	}
+}
+
+func TestMemoryReorderTasks(t *testing.T) {
+	ctx := context.Background()
+	const userID = 1
+
+	newStore := func(t *testing.T, types ...string) (*Memory, []int64) {
+		m := NewMemory()
+		ids := make([]int64, len(types))
+		for i, typ := range types {
+			task := &models.Task{UserID: userID, Title: typ, TaskType: typ}
+			if err := m.CreateTask(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+			ids[i] = task.ID
+		}
+		return m, ids
+	}
+	order := func(t *testing.T, m *Memory) []int64 {
+		tasks, err := m.ListTasks(ctx, userID, TaskFilter{Type: "Task", Manual: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]int64, len(tasks))
+		for i, task := range tasks {
+			got[i] = task.ID
+		}
+		return got
+	}
+
+	t.Run("subset stays within its slots", func(t *testing.T) {
+		m, ids := newStore(t, "Task", "Task", "Task", "Task")
+		if err := m.ReorderTasks(ctx, userID, ids); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.ReorderTasks(ctx, userID, []int64{ids[2], ids[0]}); err != nil {
+			t.Fatal(err)
+		}
+		want := []int64{ids[2], ids[1], ids[0], ids[3]}
+		if got := order(t, m); !reflect.DeepEqual(got, want) {
+			t.Errorf("order = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("duplicate ids", func(t *testing.T) {
+		m, ids := newStore(t, "Task", "Task")
+		err := m.ReorderTasks(ctx, userID, []int64{ids[0], ids[1], ids[0]})
+		if !errors.Is(err, ErrInvalidOrder) {
+			t.Errorf("err = %v, want ErrInvalidOrder", err)
+		}
+	})
+
+	t.Run("mixed lists", func(t *testing.T) {
+		m, ids := newStore(t, "Task", "Long")
+		err := m.ReorderTasks(ctx, userID, []int64{ids[1], ids[0]})
+		if !errors.Is(err, ErrInvalidOrder) {
+			t.Errorf("err = %v, want ErrInvalidOrder", err)
+		}
+	})
+
+	t.Run("other user's task", func(t *testing.T) {
+		m, ids := newStore(t, "Task")
+		err := m.ReorderTasks(ctx, userID+1, ids)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,491 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/enkinvsh/focus-backend/internal/recurrence"
+	"github.com/enkinvsh/focus-backend/internal/stats"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres implements TaskStore, UserStore and ActivityStore on pgx.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+const taskColumns = `id, user_id, title, COALESCE(original_input, ''), task_type, priority, completed, completed_at,
+	due_at, reminder_sent, recurrence, next_occurrence::text, archived_at, parent_id, position,
+	(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = tasks.id),
+	(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = tasks.id AND s.completed),
+	created_at, updated_at`
+
+func scanTask(row pgx.Row, t *models.Task) error {
+	var total, done int
+	err := row.Scan(&t.ID, &t.UserID, &t.Title, &t.OriginalInput, &t.TaskType, &t.Priority, &t.Completed, &t.CompletedAt,
+		&t.DueAt, &t.ReminderSent, &t.Recurrence, &t.NextOccurrence, &t.ArchivedAt, &t.ParentID, &t.Position,
+		&total, &done, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	t.SetProgress(total, done)
+	return nil
+}
+
+func scanTasks(rows pgx.Rows) ([]models.Task, error) {
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var t models.Task
+		if err := scanTask(rows, &t); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// ListTasks puts tasks that were never moved first in manual order, so new
+// tasks show up at the top.
+func (s *Postgres) ListTasks(ctx context.Context, userID int64, f TaskFilter) ([]models.Task, error) {
+	order := "priority ASC, created_at DESC, id"
+	if f.Manual {
+		order = "position ASC NULLS FIRST, priority ASC, created_at DESC, id"
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE user_id = $1 AND task_type = $2 AND completed = $3 AND (archived_at IS NOT NULL) = $6
+			AND parent_id IS NULL
+		ORDER BY `+order+`
+		LIMIT $4 OFFSET $5
+	`, userID, f.Type, f.Completed, f.Limit, f.Offset, f.Archived)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+func (s *Postgres) GetTask(ctx context.Context, userID, taskID int64) (*models.Task, error) {
+	var t models.Task
+	err := scanTask(s.pool.QueryRow(ctx, `
+		SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND user_id = $2
+	`, taskID, userID), &t)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Postgres) CreateTask(ctx context.Context, t *models.Task) error {
+	return s.pool.QueryRow(ctx, `
+		INSERT INTO tasks (user_id, title, original_input, task_type, priority, due_at, recurrence, next_occurrence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::date)
+		RETURNING id, created_at, updated_at
+	`, t.UserID, t.Title, t.OriginalInput, t.TaskType, t.Priority, t.DueAt, t.Recurrence, t.NextOccurrence).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// UpdateTask sets completed_at with completed, and re-arms reminder_sent
+// only when due_at actually changes. A subtask's parent is locked first, so
+// concurrent check-offs of its last two subtasks can't both miss the
+// all-done state.
+func (s *Postgres) UpdateTask(ctx context.Context, userID, taskID int64, u TaskUpdate) (*TaskChange, error) {
+	var completedAt *time.Time
+	if u.Completed != nil && *u.Completed {
+		now := time.Now()
+		completedAt = &now
+	}
+
+	var change TaskChange
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		parentID, err := lockParent(ctx, tx, userID, taskID)
+		if err != nil {
+			return err
+		}
+
+		var completed bool
+		err = tx.QueryRow(ctx, `
+			UPDATE tasks t
+			SET 
+				title = COALESCE($1, t.title),
+				priority = COALESCE($2, t.priority),
+				completed = COALESCE($3, t.completed),
+				completed_at = CASE WHEN $3::boolean IS NULL THEN t.completed_at ELSE $4 END,
+				reminder_sent = CASE WHEN $7 AND t.due_at IS DISTINCT FROM $8::timestamptz THEN FALSE ELSE t.reminder_sent END,
+				due_at = CASE WHEN $7 THEN $8::timestamptz ELSE t.due_at END,
+				recurrence = COALESCE($9::jsonb, t.recurrence),
+				next_occurrence = COALESCE($10::date, t.next_occurrence),
+				updated_at = NOW()
+			FROM (SELECT id, completed FROM tasks WHERE id = $5 AND user_id = $6 FOR UPDATE) old
+			WHERE t.id = old.id
+			RETURNING t.title, old.completed, t.completed
+		`, u.Title, u.Priority, u.Completed, completedAt, taskID, userID, u.DueSet, u.DueAt,
+			u.Recurrence, u.NextOccurrence).Scan(&change.Title, &change.WasCompleted, &completed)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if parentID != 0 && completed != change.WasCompleted {
+			change.Parent, err = syncParent(ctx, tx, parentID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// lockParent locks the parent of a subtask and returns its ID, or zero for
+// top-level and missing tasks.
+func lockParent(ctx context.Context, tx pgx.Tx, userID, taskID int64) (int64, error) {
+	var parentID int64
+	err := tx.QueryRow(ctx, `
+		SELECT p.id FROM tasks c JOIN tasks p ON p.id = c.parent_id
+		WHERE c.id = $1 AND c.user_id = $2
+		FOR UPDATE OF p
+	`, taskID, userID).Scan(&parentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return parentID, err
+}
+
+// syncParent completes the parent once all of its subtasks are done, and
+// reopens it while any is open. The caller holds the parent's row lock.
+func syncParent(ctx context.Context, tx pgx.Tx, parentID int64) (*ParentChange, error) {
+	change := ParentChange{ID: parentID}
+	err := tx.QueryRow(ctx, `
+		UPDATE tasks p
+		SET completed = s.done, completed_at = CASE WHEN s.done THEN NOW() END, updated_at = NOW()
+		FROM (SELECT bool_and(completed) AS done FROM tasks WHERE parent_id = $1) s
+		WHERE p.id = $1 AND s.done IS NOT NULL AND p.completed <> s.done
+		RETURNING p.title, p.completed
+	`, parentID).Scan(&change.Title, &change.Completed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (s *Postgres) CompleteRoutine(ctx context.Context, userID, taskID int64, loc *time.Location) (*recurrence.Completion, error) {
+	var c *recurrence.Completion
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		c, err = recurrence.Complete(ctx, tx, userID, taskID, loc)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *Postgres) DeleteTask(ctx context.Context, userID, taskID int64) (*TaskChange, error) {
+	var change TaskChange
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		parentID, err := lockParent(ctx, tx, userID, taskID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `
+			DELETE FROM tasks WHERE id = $1 AND user_id = $2
+			RETURNING title, completed
+		`, taskID, userID).Scan(&change.Title, &change.WasCompleted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if parentID != 0 {
+			change.Parent, err = syncParent(ctx, tx, parentID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// ReorderTasks locks the whole list the tasks belong to and merges the new
+// order into it, so the new positions stay between the tasks the client
+// didn't send.
+func (s *Postgres) ReorderTasks(ctx context.Context, userID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if duplicateIDs(ids) {
+		return ErrInvalidOrder
+	}
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// All listed tasks must come from one list.
+		var lists, found int
+		var taskType string
+		var completed, archived bool
+		err := tx.QueryRow(ctx, `
+			SELECT COUNT(DISTINCT (task_type, completed, archived_at IS NOT NULL)), COUNT(*),
+				COALESCE(MIN(task_type), ''), COALESCE(bool_and(completed), FALSE),
+				COALESCE(bool_and(archived_at IS NOT NULL), FALSE)
+			FROM tasks
+			WHERE user_id = $1 AND id = ANY($2) AND parent_id IS NULL
+		`, userID, ids).Scan(&lists, &found, &taskType, &completed, &archived)
+		if err != nil {
+			return err
+		}
+		if found != len(ids) {
+			return ErrNotFound
+		}
+		if lists != 1 {
+			return ErrInvalidOrder
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT id, position FROM tasks
+			WHERE user_id = $1 AND task_type = $2 AND completed = $3 AND (archived_at IS NOT NULL) = $4
+				AND parent_id IS NULL
+			ORDER BY position ASC NULLS FIRST, priority ASC, created_at DESC, id
+			FOR UPDATE
+		`, userID, taskType, completed, archived)
+		if err != nil {
+			return err
+		}
+		var order []int64
+		current := map[int64]*float64{}
+		for rows.Next() {
+			var id int64
+			var pos *float64
+			if err := rows.Scan(&id, &pos); err != nil {
+				rows.Close()
+				return err
+			}
+			order = append(order, id)
+			current[id] = pos
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		updates := reorderPositions(mergeOrder(order, ids), current)
+		if len(updates) == 0 {
+			return nil
+		}
+		moved := make([]int64, 0, len(updates))
+		positions := make([]float64, 0, len(updates))
+		for id, pos := range updates {
+			moved = append(moved, id)
+			positions = append(positions, pos)
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE tasks t SET position = u.position, updated_at = NOW()
+			FROM unnest($1::bigint[], $2::float8[]) AS u(id, position)
+			WHERE t.id = u.id AND t.user_id = $3
+		`, moved, positions, userID)
+		return err
+	})
+}
+
+func (s *Postgres) ListSubtasks(ctx context.Context, userID, parentID int64) ([]models.Task, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2)
+	`, parentID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE parent_id = $1 AND user_id = $2
+		ORDER BY position, id
+	`, parentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// CreateSubtask locks the parent, so concurrent inserts get distinct
+// positions, and reopens it if it was completed.
+func (s *Postgres) CreateSubtask(ctx context.Context, t *models.Task) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var id int64
+		err := tx.QueryRow(ctx, `
+			SELECT id FROM tasks WHERE id = $1 AND user_id = $2 FOR UPDATE
+		`, t.ParentID, t.UserID).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO tasks (user_id, parent_id, title, original_input, task_type, priority, position)
+			SELECT $1, $2, $3, $4, $5, $6, COALESCE(MAX(position), 0) + 1
+			FROM tasks WHERE parent_id = $2
+			RETURNING id, position, created_at, updated_at
+		`, t.UserID, t.ParentID, t.Title, t.OriginalInput, t.TaskType, t.Priority).
+			Scan(&t.ID, &t.Position, &t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = syncParent(ctx, tx, id)
+		return err
+	})
+}
+
+// ReorderSubtasks numbers the subtasks 1..n. Checklists are short, so it
+// rewrites them all under a lock on the parent.
+func (s *Postgres) ReorderSubtasks(ctx context.Context, userID, parentID int64, ids []int64) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var id int64
+		err := tx.QueryRow(ctx, `
+			SELECT id FROM tasks WHERE id = $1 AND user_id = $2 FOR UPDATE
+		`, parentID, userID).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `SELECT id FROM tasks WHERE parent_id = $1`, parentID)
+		if err != nil {
+			return err
+		}
+		current, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+		if !sameIDs(current, ids) {
+			return ErrInvalidOrder
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE tasks t SET position = o.ord, updated_at = NOW()
+			FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, ord)
+			WHERE t.id = o.id AND t.parent_id = $2
+		`, ids, parentID)
+		return err
+	})
+}
+
+func (s *Postgres) EnsureUser(ctx context.Context, p db.Profile) error {
+	return db.EnsureUser(ctx, s.pool, p)
+}
+
+func (s *Postgres) Location(ctx context.Context, userID int64) *time.Location {
+	return db.UserLocation(ctx, s.pool, userID)
+}
+
+func (s *Postgres) Preferences(ctx context.Context, userID int64) (*models.User, error) {
+	u := DefaultPreferences()
+	u.ID = userID
+	err := s.pool.QueryRow(ctx, `
+		SELECT language, timezone, theme_index, COALESCE(digest_enabled, FALSE), COALESCE(digest_hour, 8),
+			COALESCE(review_enabled, TRUE), COALESCE(task_order, 'priority')
+		FROM users WHERE id = $1
+	`, userID).Scan(&u.Language, &u.Timezone, &u.ThemeIndex, &u.DigestEnabled, &u.DigestHour, &u.ReviewEnabled,
+		&u.TaskOrder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &u, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *Postgres) UpdatePreferences(ctx context.Context, p db.Profile, u PreferencesUpdate) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO users (id, first_name, username, language, timezone, theme_index, digest_enabled, digest_hour, review_enabled,
+			task_order)
+		VALUES ($1, $2, $3, COALESCE($4, 'en'), COALESCE($5, 'UTC'), COALESCE($6, 0), COALESCE($7, FALSE), COALESCE($8, 8), COALESCE($9, TRUE),
+			COALESCE($10, 'priority'))
+		ON CONFLICT (id) DO UPDATE SET
+			language = COALESCE($4, users.language),
+			timezone = COALESCE($5, users.timezone),
+			theme_index = COALESCE($6, users.theme_index),
+			digest_enabled = COALESCE($7, users.digest_enabled),
+			digest_hour = COALESCE($8, users.digest_hour),
+			review_enabled = COALESCE($9, users.review_enabled),
+			task_order = COALESCE($10, users.task_order),
+			updated_at = NOW()
+	`, p.ID, p.FirstName, p.Username, u.Language, u.Timezone, u.ThemeIndex, u.DigestEnabled, u.DigestHour,
+		u.ReviewEnabled, u.TaskOrder)
+	return err
+}
+
+func (s *Postgres) RecordEvent(ctx context.Context, userID int64, eventType string, metadata map[string]interface{}) {
+	events.Write(ctx, s.pool, userID, eventType, metadata)
+}
+
+func (s *Postgres) ListEvents(ctx context.Context, userID int64, types []string, before int64, limit int) ([]events.Event, error) {
+	return events.List(ctx, s.pool, userID, types, before, limit)
+}
+
+func (s *Postgres) CreateBreathingSession(ctx context.Context, userID int64, b *models.BreathingSession) error {
+	return s.pool.QueryRow(ctx, `
+		INSERT INTO breathing_sessions (user_id, started_at, ended_at, cycles, aborted)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, userID, b.StartedAt, b.EndedAt, b.Cycles, b.Aborted).Scan(&b.ID, &b.CreatedAt)
+}
+
+func (s *Postgres) ListBreathingSessions(ctx context.Context, userID int64, limit, offset int) ([]models.BreathingSession, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, started_at, ended_at, cycles, aborted, created_at
+		FROM breathing_sessions
+		WHERE user_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.BreathingSession{}
+	for rows.Next() {
+		var b models.BreathingSession
+		if err := rows.Scan(&b.ID, &b.StartedAt, &b.EndedAt, &b.Cycles, &b.Aborted, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, b)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *Postgres) Stats(ctx context.Context, userID int64, loc *time.Location, from, to, today time.Time) (*stats.Stats, error) {
+	return stats.Build(ctx, s.pool, userID, loc, from, to, today)
+}
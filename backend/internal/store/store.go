@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/enkinvsh/focus-backend/internal/db"
+	"github.com/enkinvsh/focus-backend/internal/events"
+	"github.com/enkinvsh/focus-backend/internal/models"
+	"github.com/enkinvsh/focus-backend/internal/recurrence"
+	"github.com/enkinvsh/focus-backend/internal/stats"
+)
+
+var (
+	// ErrNotFound is returned when a task doesn't exist for the user.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidOrder is returned when a reorder lists a task twice, or
+	// doesn't list exactly the subtasks being ordered.
+	ErrInvalidOrder = errors.New("ids must list each task exactly once")
+)
+
+// TaskFilter selects top-level tasks; subtasks are listed with their parent.
+type TaskFilter struct {
+	Type      string
+	Completed bool
+	Archived  bool
+	Manual    bool // order by position instead of priority
+	Limit     int
+	Offset    int
+}
+
+// TaskUpdate is a partial update; nil fields are left unchanged. DueSet with
+// a nil DueAt clears the due date.
+type TaskUpdate struct {
+	Title          *string
+	Priority       *int
+	Completed      *bool
+	DueSet         bool
+	DueAt          *time.Time
+	Recurrence     *recurrence.Rule
+	NextOccurrence *string // YYYY-MM-DD
+}
+
+// TaskChange reports what UpdateTask or DeleteTask did.
+type TaskChange struct {
+	Title        string
+	WasCompleted bool
+	// Parent is set when the change to a subtask completed or reopened its
+	// parent.
+	Parent *ParentChange
+}
+
+// ParentChange is a parent task brought in line with its subtasks.
+type ParentChange struct {
+	ID        int64
+	Title     string
+	Completed bool
+}
+
+type TaskStore interface {
+	ListTasks(ctx context.Context, userID int64, f TaskFilter) ([]models.Task, error)
+	GetTask(ctx context.Context, userID, taskID int64) (*models.Task, error)
+	// CreateTask inserts t and fills in its ID and timestamps.
+	CreateTask(ctx context.Context, t *models.Task) error
+	// UpdateTask applies u. Completing the last open subtask completes the
+	// parent; reopening a subtask reopens it.
+	UpdateTask(ctx context.Context, userID, taskID int64, u TaskUpdate) (*TaskChange, error)
+	// CompleteRoutine logs today's occurrence of a recurring task; it
+	// returns recurrence.ErrNotRecurring for one-off tasks.
+	CompleteRoutine(ctx context.Context, userID, taskID int64, loc *time.Location) (*recurrence.Completion, error)
+	// DeleteTask deletes the task and its subtasks. Deleting the last open
+	// subtask completes the parent.
+	DeleteTask(ctx context.Context, userID, taskID int64) (*TaskChange, error)
+	// ReorderTasks puts the listed top-level tasks in the order of ids,
+	// moving as few of them as it can. It returns ErrNotFound if any of
+	// them doesn't exist for the user.
+	ReorderTasks(ctx context.Context, userID int64, ids []int64) error
+
+	// ListSubtasks returns the parent's subtasks in checklist order.
+	ListSubtasks(ctx context.Context, userID, parentID int64) ([]models.Task, error)
+	// CreateSubtask inserts t under t.ParentID at the end of the checklist,
+	// reopening the parent if it was completed.
+	CreateSubtask(ctx context.Context, t *models.Task) error
+	// ReorderSubtasks puts the parent's subtasks in the order of ids.
+	ReorderSubtasks(ctx context.Context, userID, parentID int64, ids []int64) error
+}
+
+// PreferencesUpdate is a partial update of the user's settings.
+type PreferencesUpdate struct {
+	Language      *string
+	Timezone      *string
+	ThemeIndex    *int
+	DigestEnabled *bool
+	DigestHour    *int
+	ReviewEnabled *bool
+	TaskOrder     *string
+}
+
+type UserStore interface {
+	EnsureUser(ctx context.Context, p db.Profile) error
+	// Location returns the user's timezone, falling back to UTC.
+	Location(ctx context.Context, userID int64) *time.Location
+	// Preferences returns the defaults for users without a row.
+	Preferences(ctx context.Context, userID int64) (*models.User, error)
+	UpdatePreferences(ctx context.Context, p db.Profile, u PreferencesUpdate) error
+}
+
+// ActivityStore holds the activity log and breathing sessions, and the
+// stats built over them.
+type ActivityStore interface {
+	// RecordEvent has the events.Recorder signature; failures are logged,
+	// never returned.
+	RecordEvent(ctx context.Context, userID int64, eventType string, metadata map[string]interface{})
+	// ListEvents returns the user's events newest first. before is an
+	// exclusive event ID cursor (0 for the first page); no types means all.
+	ListEvents(ctx context.Context, userID int64, types []string, before int64, limit int) ([]events.Event, error)
+	// CreateBreathingSession inserts b and fills in its ID and CreatedAt.
+	CreateBreathingSession(ctx context.Context, userID int64, b *models.BreathingSession) error
+	// ListBreathingSessions returns the user's sessions, newest first.
+	ListBreathingSessions(ctx context.Context, userID int64, limit, offset int) ([]models.BreathingSession, error)
+	// Stats aggregates the user's activity over the local dates [from, to],
+	// bucketed in loc. today anchors the streaks.
+	Stats(ctx context.Context, userID int64, loc *time.Location, from, to, today time.Time) (*stats.Stats, error)
+}
+
+// DefaultPreferences are the settings of a user who hasn't changed any.
+func DefaultPreferences() models.User {
+	return models.User{Language: "en", Timezone: "UTC", DigestEnabled: false, DigestHour: 8, ReviewEnabled: true,
+		TaskOrder: models.TaskOrderPriority}
+}
@@ -0,0 +1,108 @@
+package timezone
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Validation must not depend on the host having zoneinfo installed.
+	_ "time/tzdata"
+)
+
+const (
+	// Offsets reported by clients must fall within real-world bounds.
+	minOffset = -12 * 60
+	maxOffset = 14 * 60
+)
+
+var ErrInvalid = errors.New("invalid timezone")
+
+// Load resolves a stored timezone: an IANA name such as "Europe/Moscow", or
+// a fixed offset "UTC+05:30" saved from a client-reported offset.
+//
+// Fixed zones are named with the POSIX spec "<+0530>-05:30", so
+// loc.String() can be passed to Postgres AT TIME ZONE for either kind.
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, ErrInvalid
+	}
+	if rest, ok := strings.CutPrefix(name, "UTC"); ok && rest != "" {
+		minutes, err := parseOffset(rest)
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		return fixed(minutes), nil
+	}
+	// LoadLocation maps "Local" to the server's zone, which means nothing
+	// for a user.
+	if name == "Local" {
+		return nil, ErrInvalid
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	return loc, nil
+}
+
+// FromOffset returns the stored name for an offset in minutes east of UTC
+// (the negated JavaScript getTimezoneOffset()).
+func FromOffset(minutes int) (string, error) {
+	if minutes < minOffset || minutes > maxOffset || minutes%15 != 0 {
+		return "", ErrInvalid
+	}
+	if minutes == 0 {
+		return "UTC", nil
+	}
+	return "UTC" + formatOffset(minutes), nil
+}
+
+// Today returns local midnight of the current day in loc.
+func Today(loc *time.Location) time.Time {
+	return StartOfDay(time.Now().In(loc))
+}
+
+// StartOfDay returns midnight of t's date in t's location.
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func fixed(minutes int) *time.Location {
+	// POSIX offsets count west of UTC, hence the flipped sign. Quoted
+	// abbreviations may not contain ':'.
+	abbr := strings.ReplaceAll(formatOffset(minutes), ":", "")
+	return time.FixedZone(fmt.Sprintf("<%s>%s", abbr, formatOffset(-minutes)), minutes*60)
+}
+
+func formatOffset(minutes int) string {
+	sign := "+"
+	if minutes < 0 {
+		sign = "-"
+		minutes = -minutes
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, minutes/60, minutes%60)
+}
+
+func parseOffset(s string) (int, error) {
+	if len(s) != len("+05:30") || (s[0] != '+' && s[0] != '-') || s[3] != ':' {
+		return 0, ErrInvalid
+	}
+	h, err := strconv.Atoi(s[1:3])
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	m, err := strconv.Atoi(s[4:])
+	if err != nil || m >= 60 {
+		return 0, ErrInvalid
+	}
+	minutes := h*60 + m
+	if s[0] == '-' {
+		minutes = -minutes
+	}
+	if minutes < minOffset || minutes > maxOffset {
+		return 0, ErrInvalid
+	}
+	return minutes, nil
+}